@@ -0,0 +1,216 @@
+package grin
+
+import "sync/atomic"
+
+// Acked is a SPSC ring buffer with at-least-once delivery. Pop marks an item as
+// in flight rather than freeing its slot, and the slot only returns to the producer
+// once the consumer acknowledges the item with Ack.
+//
+// The consumer has three cursors: read, the next sequence Pop delivers, delivered,
+// one past the highest sequence ever delivered, and committed, below which every
+// item has been acknowledged. Nack, Rewind and the visibility timeout move read
+// back, redelivering every unacknowledged item from there on in order (go-back-N),
+// so a consumer that crashes mid-batch loses nothing. They never move delivered
+// back, so items acknowledged before a Nack are still committed past.
+type Acked[T any] struct {
+	store       []T
+	acked       []bool  // Owned by the consumer, by slot
+	deliveredAt []int64 // Owned by the consumer, by slot, only with a visibility timeout
+	mask        uint64
+	visibility  int64
+	clearOnPop  bool
+	wait        WaitStrategy
+	_           [cacheLineSize]byte // Do not remove
+
+	committed atomic.Uint64           // Published by the consumer, producer must use atomic operations to read
+	_         [cacheLineSize - 8]byte // Do not remove
+
+	tail atomic.Uint64           // Published by the producer, consumer must use atomic operations to read
+	_    [cacheLineSize - 8]byte // Do not remove
+
+	read      uint64                   // Owned by the consumer, between committed and delivered
+	delivered uint64                   // Owned by the consumer, between read and tail
+	_         [cacheLineSize - 16]byte // Do not remove
+}
+
+// NewAcked creates a new ring buffer with the specified size, which must be a power
+// of 2. Use WithVisibilityTimeout to redeliver items that are never acknowledged.
+// WithWaitStrategy is notified on every Push and on every Ack that frees slots.
+func NewAcked[T any](size int, opts ...Option) *Acked[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	a := &Acked[T]{
+		store:      make([]T, size),
+		acked:      make([]bool, size),
+		mask:       uint64(size) - 1,
+		visibility: int64(o.visibility),
+		clearOnPop: o.clearOnPop,
+		wait:       o.wait,
+	}
+	if o.visibility > 0 {
+		a.deliveredAt = make([]int64, size)
+	}
+	return a
+}
+
+// Push adds an item to the ring buffer.
+// Returns false if the buffer is full, including unacknowledged items (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (a *Acked[T]) Push(t T) bool {
+	tail := a.tail.Load()
+	if tail-a.committed.Load() == uint64(len(a.store)) {
+		return false
+	}
+
+	a.store[tail&a.mask] = t
+	a.tail.Store(tail + 1)
+	if a.wait != nil {
+		a.wait.Notify()
+	}
+	return true
+}
+
+// Pop delivers the next item and its sequence, which must be passed to Ack or
+// Nack. The item stays in flight until then.
+// Returns (zero value, 0, false) if there is nothing to deliver (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (a *Acked[T]) Pop() (T, uint64, bool) {
+	committed := a.committed.Load()
+
+	var now int64
+	if a.visibility > 0 && committed != a.read {
+		// The oldest in-flight item is the first to time out
+		now = monotonic()
+		if now-a.deliveredAt[committed&a.mask] >= a.visibility {
+			a.read = committed
+		}
+	}
+
+	tail := a.tail.Load()
+	read := a.read
+	for read != tail && a.acked[read&a.mask] {
+		read++
+	}
+	if read == tail {
+		a.read = read
+		var zero T
+		return zero, 0, false
+	}
+
+	if a.visibility > 0 {
+		if now == 0 {
+			now = monotonic()
+		}
+		a.deliveredAt[read&a.mask] = now
+	}
+	a.read = read + 1
+	if a.read > a.delivered {
+		a.delivered = a.read
+	}
+	return a.store[read&a.mask], read, true
+}
+
+// Ack acknowledges the item with the given sequence. Its slot, and those of any
+// acknowledged items after it, return to the producer once every item before it
+// has been acknowledged too.
+// Returns false if seq is not in flight.
+//
+// Only safe to call from a single consumer goroutine.
+func (a *Acked[T]) Ack(seq uint64) bool {
+	committed := a.committed.Load()
+	if !a.inFlight(committed, seq) {
+		return false
+	}
+
+	a.acked[seq&a.mask] = true
+	if seq != committed {
+		return true
+	}
+
+	// Items after read may have been acknowledged before a Nack, so commit up to
+	// everything ever delivered
+	for committed != a.delivered && a.acked[committed&a.mask] {
+		slot := committed & a.mask
+		a.acked[slot] = false
+		if a.clearOnPop {
+			// Release the reference so the GC can reclaim acknowledged values
+			var zero T
+			a.store[slot] = zero
+		}
+		committed++
+	}
+	if a.read < committed {
+		a.read = committed
+	}
+	a.committed.Store(committed)
+	if a.wait != nil {
+		a.wait.Notify()
+	}
+	return true
+}
+
+// Nack makes the item with the given sequence available to Pop again, along with
+// every unacknowledged item delivered after it. Those items can't be acknowledged
+// until they have been redelivered.
+// Returns false if seq is not in flight.
+//
+// Only safe to call from a single consumer goroutine.
+func (a *Acked[T]) Nack(seq uint64) bool {
+	if !a.inFlight(a.committed.Load(), seq) {
+		return false
+	}
+
+	a.read = seq
+	return true
+}
+
+// Rewind makes every unacknowledged item available to Pop again, such as when a
+// new consumer takes over from one that crashed.
+//
+// Only safe to call from a single consumer goroutine.
+func (a *Acked[T]) Rewind() {
+	a.read = a.committed.Load()
+}
+
+// inFlight reports whether seq has been delivered and not yet acknowledged.
+func (a *Acked[T]) inFlight(committed, seq uint64) bool {
+	return seq-committed < a.read-committed && !a.acked[seq&a.mask]
+}
+
+// InFlight returns the number of items delivered by Pop and not yet acknowledged
+// or redelivered.
+//
+// Only safe to call from a single consumer goroutine.
+func (a *Acked[T]) InFlight() int {
+	n := 0
+	for seq := a.committed.Load(); seq != a.read; seq++ {
+		if !a.acked[seq&a.mask] {
+			n++
+		}
+	}
+	return n
+}
+
+func (a *Acked[T]) Cap() int {
+	return len(a.store)
+}
+
+// Len includes items that are in flight.
+func (a *Acked[T]) Len() int {
+	tail := a.tail.Load()
+	committed := a.committed.Load()
+	return int(tail - committed)
+}
+
+func (a *Acked[T]) Available() int {
+	return a.Cap() - a.Len()
+}
@@ -0,0 +1,246 @@
+package grin_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestAckedAckFreesSlots(t *testing.T) {
+	buf := grin.NewAcked[int](2)
+	buf.Push(1)
+	buf.Push(2)
+
+	v, seq, ok := buf.Pop()
+	if !ok || v != 1 || seq != 0 {
+		t.Fatalf("Pop() = (%d, %d, %v), want (1, 0, true)", v, seq, ok)
+	}
+	if buf.Push(3) {
+		t.Error("Push() should fail while popped items are unacknowledged")
+	}
+
+	if !buf.Ack(seq) {
+		t.Errorf("Ack(%d) = false, want true", seq)
+	}
+	if buf.Ack(seq) {
+		t.Errorf("Ack(%d) twice = true, want false", seq)
+	}
+	if !buf.Push(3) {
+		t.Error("Push() should succeed once the item is acknowledged")
+	}
+}
+
+func TestAckedOutOfOrderAck(t *testing.T) {
+	buf := grin.NewAcked[int](4)
+	for i := 0; i < 4; i++ {
+		buf.Push(i)
+	}
+	for i := 0; i < 3; i++ {
+		buf.Pop()
+	}
+
+	buf.Ack(2)
+	buf.Ack(1)
+	if buf.Len() != 4 || buf.InFlight() != 1 {
+		t.Errorf("Len() = %d, InFlight() = %d, want 4 and 1", buf.Len(), buf.InFlight())
+	}
+
+	buf.Ack(0)
+	if buf.Len() != 1 || buf.InFlight() != 0 {
+		t.Errorf("Len() = %d, InFlight() = %d, want 1 and 0", buf.Len(), buf.InFlight())
+	}
+
+	if v, seq, ok := buf.Pop(); !ok || v != 3 || seq != 3 {
+		t.Errorf("Pop() = (%d, %d, %v), want (3, 3, true)", v, seq, ok)
+	}
+}
+
+func TestAckedNackRedelivers(t *testing.T) {
+	buf := grin.NewAcked[string](8)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		buf.Push(s)
+	}
+	for i := 0; i < 4; i++ {
+		buf.Pop()
+	}
+
+	buf.Ack(0)
+	buf.Ack(2)
+	if !buf.Nack(1) {
+		t.Error("Nack(1) = false, want true")
+	}
+
+	// c was acknowledged, so only b and d come back
+	for _, want := range []string{"b", "d"} {
+		if v, _, ok := buf.Pop(); !ok || v != want {
+			t.Errorf("Pop() = (%q, %v), want (%q, true)", v, ok, want)
+		}
+	}
+	if _, _, ok := buf.Pop(); ok {
+		t.Error("Pop() should return false once everything is in flight")
+	}
+	if buf.Nack(0) || buf.Nack(100) {
+		t.Error("Nack() of an item that isn't in flight should return false")
+	}
+}
+
+// Items acknowledged after the one that is nacked must still be committed past once
+// it is redelivered and acknowledged, or their slots are lost for good.
+func TestAckedNackAfterOutOfOrderAck(t *testing.T) {
+	buf := grin.NewAcked[int](4, grin.WithVisibilityTimeout(time.Hour))
+	for round := 0; round < 4; round++ {
+		base := uint64(round * 3)
+		for i := 0; i < 3; i++ {
+			if !buf.Push(i) {
+				t.Fatalf("round %d: Push(%d) = false with Len() = %d, InFlight() = %d", round, i, buf.Len(), buf.InFlight())
+			}
+		}
+		for i := 0; i < 3; i++ {
+			buf.Pop()
+		}
+
+		buf.Ack(base + 1)
+		buf.Ack(base + 2)
+		buf.Nack(base)
+		if v, seq, ok := buf.Pop(); !ok || v != 0 || seq != base {
+			t.Fatalf("round %d: Pop() = (%d, %d, %v), want (0, %d, true)", round, v, seq, ok, base)
+		}
+		if !buf.Ack(base) {
+			t.Fatalf("round %d: Ack(%d) = false, want true", round, base)
+		}
+
+		if buf.Len() != 0 || buf.InFlight() != 0 {
+			t.Fatalf("round %d: Len() = %d, InFlight() = %d, want 0 and 0", round, buf.Len(), buf.InFlight())
+		}
+		if v, seq, ok := buf.Pop(); ok {
+			t.Fatalf("round %d: Pop() = (%d, %d, true) redelivered an acknowledged item", round, v, seq)
+		}
+	}
+}
+
+func TestAckedRewind(t *testing.T) {
+	buf := grin.NewAcked[int](8)
+	for i := 0; i < 3; i++ {
+		buf.Push(i)
+		buf.Pop()
+	}
+	buf.Ack(0)
+
+	// A new consumer takes over and sees everything unacknowledged again
+	buf.Rewind()
+	for want := 1; want < 3; want++ {
+		if v, seq, ok := buf.Pop(); !ok || v != want || seq != uint64(want) {
+			t.Errorf("Pop() = (%d, %d, %v), want (%d, %d, true)", v, seq, ok, want, want)
+		}
+	}
+}
+
+func TestAckedVisibilityTimeout(t *testing.T) {
+	buf := grin.NewAcked[int](8, grin.WithVisibilityTimeout(time.Millisecond))
+	buf.Push(1)
+
+	_, seq, _ := buf.Pop()
+	if _, _, ok := buf.Pop(); ok {
+		t.Error("Pop() should not redeliver before the visibility timeout")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	v, again, ok := buf.Pop()
+	if !ok || v != 1 || again != seq {
+		t.Errorf("Pop() = (%d, %d, %v), want (1, %d, true) after the visibility timeout", v, again, ok, seq)
+	}
+	if !buf.Ack(again) {
+		t.Errorf("Ack(%d) = false, want true", again)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", buf.Len())
+	}
+}
+
+func TestAckedNotifiesWaitStrategy(t *testing.T) {
+	w := grin.NewParkWait()
+	buf := grin.NewAcked[int](1, grin.WithWaitStrategy(w))
+
+	sel := grin.NewSelector(w)
+	readable := sel.Readable(buf)
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		buf.Push(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if got, err := sel.Select(ctx); err != nil || got != readable {
+		t.Fatalf("Select() = (%d, %v), want (%d, nil)", got, err, readable)
+	}
+
+	// Once full, the selector only wakes for the Ack that frees the slot
+	_, seq, _ := buf.Pop()
+	sel = grin.NewSelector(w)
+	writable := sel.Writable(buf)
+	go func() {
+		time.Sleep(time.Millisecond)
+		buf.Ack(seq)
+	}()
+
+	if got, err := sel.Select(ctx); err != nil || got != writable {
+		t.Fatalf("Select() = (%d, %v), want (%d, nil)", got, err, writable)
+	}
+}
+
+func TestAckedConcurrentRedelivery(t *testing.T) {
+	const n = 100000
+	buf := grin.NewAcked[int](64)
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < n; i++ {
+			for !buf.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	go func() {
+		next, nacked := 0, -1
+		for next < n {
+			v, seq, ok := buf.Pop()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if v != next {
+				t.Errorf("Pop() = %d, want %d", v, next)
+				break
+			}
+
+			// Reject every 7th item once, it must be redelivered straight away
+			if v%7 == 0 && v != nacked {
+				if !buf.Nack(seq) {
+					t.Errorf("Nack(%d) = false, want true", seq)
+					break
+				}
+				nacked = v
+				continue
+			}
+
+			if !buf.Ack(seq) {
+				t.Errorf("Ack(%d) = false, want true", seq)
+				break
+			}
+			next++
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+}
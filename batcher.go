@@ -0,0 +1,174 @@
+package grin
+
+import (
+	"context"
+	"time"
+)
+
+// BatcherConfig configures when a Batcher flushes.
+type BatcherConfig[T any] struct {
+	// MaxSize is the most items in a batch, which must be at least 1
+	MaxSize int
+
+	// MaxBytes flushes once the items in a batch add up to MaxBytes, as measured
+	// by Sizer. Zero disables it.
+	MaxBytes int
+	Sizer    func(T) int
+
+	// MaxLatency flushes a batch once its first item has waited this long.
+	// Zero disables it, so batches only flush when full or on close.
+	MaxLatency time.Duration
+}
+
+// Batcher drains a consumer handle into batches, calling flush when a batch hits
+// its maximum size, bytes or latency, and when Run returns.
+type Batcher[T any] struct {
+	src interface {
+		Pop() (T, bool)
+		Readable() <-chan struct{}
+	}
+	cfg   BatcherConfig[T]
+	flush func([]T) error
+
+	batch    []T
+	bytes    int
+	deadline time.Time // When the current batch must flush, zero while empty
+	timer    *time.Timer
+}
+
+// NewBatcher creates a Batcher that pops from src, which is usually a RingBuffer
+// or Local. flush is called with a batch that is reused once it returns, so it
+// must not keep it.
+func NewBatcher[T any](src interface {
+	Pop() (T, bool)
+	Readable() <-chan struct{}
+}, cfg BatcherConfig[T], flush func([]T) error) *Batcher[T] {
+	if cfg.MaxSize < 1 {
+		panic("max batch size must be at least 1")
+	}
+	if cfg.MaxBytes > 0 && cfg.Sizer == nil {
+		panic("max batch bytes requires a sizer")
+	}
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	return &Batcher[T]{
+		src:   src,
+		cfg:   cfg,
+		flush: flush,
+		batch: make([]T, 0, cfg.MaxSize),
+		timer: timer,
+	}
+}
+
+// Run pops and batches items until ctx is done or flush returns an error. When ctx
+// is done it drains what is left in src, flushes the final batch and returns
+// ctx.Err().
+//
+// Only safe to call from a single consumer goroutine.
+func (b *Batcher[T]) Run(ctx context.Context) error {
+	for {
+		// Get the channel before the Pop that finds src empty
+		readable := b.src.Readable()
+		if err := b.drain(); err != nil {
+			return err
+		}
+
+		var timeout <-chan time.Time
+		if len(b.batch) > 0 && b.cfg.MaxLatency > 0 {
+			b.timer.Reset(time.Until(b.deadline))
+			timeout = b.timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			b.timer.Stop()
+			if err := b.drain(); err != nil {
+				return err
+			}
+			if err := b.Flush(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-readable:
+			b.timer.Stop()
+		case <-timeout:
+			if err := b.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// drain adds everything in src to the batch, flushing as the limits are hit.
+func (b *Batcher[T]) drain() error {
+	for n := 1; ; n++ {
+		t, ok := b.src.Pop()
+		if !ok {
+			break
+		}
+		if err := b.add(t); err != nil {
+			return err
+		}
+
+		// Check the clock now and then so that a busy producer can't hold back a batch
+		if n%64 == 0 && b.expired() {
+			if err := b.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if b.expired() {
+		return b.Flush()
+	}
+	return nil
+}
+
+func (b *Batcher[T]) add(t T) error {
+	size := 0
+	if b.cfg.MaxBytes > 0 {
+		size = b.cfg.Sizer(t)
+		// Keep the batch under MaxBytes unless a single item is bigger than that
+		if len(b.batch) > 0 && b.bytes+size > b.cfg.MaxBytes {
+			if err := b.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(b.batch) == 0 && b.cfg.MaxLatency > 0 {
+		b.deadline = time.Now().Add(b.cfg.MaxLatency)
+	}
+	b.batch = append(b.batch, t)
+	b.bytes += size
+
+	if len(b.batch) == b.cfg.MaxSize || (b.cfg.MaxBytes > 0 && b.bytes >= b.cfg.MaxBytes) {
+		return b.Flush()
+	}
+	return nil
+}
+
+func (b *Batcher[T]) expired() bool {
+	return len(b.batch) > 0 && b.cfg.MaxLatency > 0 && !time.Now().Before(b.deadline)
+}
+
+// Flush calls flush with the current batch, if it isn't empty, and starts a new
+// one.
+//
+// Only safe to call from the goroutine calling Run, or instead of Run.
+func (b *Batcher[T]) Flush() error {
+	if len(b.batch) == 0 {
+		return nil
+	}
+
+	err := b.flush(b.batch)
+
+	// Release references held by the reused batch
+	clear(b.batch)
+	b.batch = b.batch[:0]
+	b.bytes = 0
+	b.deadline = time.Time{}
+	return err
+}
@@ -0,0 +1,191 @@
+package grin_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+// runBatcher runs b until the returned stop func is called, which returns Run's error.
+func runBatcher[T any](t *testing.T, b *grin.Batcher[T]) (stop func() error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Run(ctx)
+	}()
+
+	return func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(10 * time.Second):
+			t.Fatal("Test timed out - Run did not return")
+			return nil
+		}
+	}
+}
+
+func TestBatcherMaxSize(t *testing.T) {
+	buf := grin.New[int](64)
+	flushed := make(chan []int, 16)
+	b := grin.NewBatcher[int](buf, grin.BatcherConfig[int]{MaxSize: 4}, func(batch []int) error {
+		flushed <- slices.Clone(batch)
+		return nil
+	})
+	stop := runBatcher(t, b)
+
+	for i := 0; i < 8; i++ {
+		buf.Push(i)
+	}
+
+	for want := 0; want < 8; want += 4 {
+		select {
+		case batch := <-flushed:
+			if !slices.Equal(batch, []int{want, want + 1, want + 2, want + 3}) {
+				t.Errorf("Batch = %v, want 4 items from %d", batch, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Test timed out - full batch was not flushed")
+		}
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestBatcherMaxBytes(t *testing.T) {
+	buf := grin.New[string](64)
+	var batches [][]string
+	b := grin.NewBatcher[string](buf, grin.BatcherConfig[string]{
+		MaxSize:  100,
+		MaxBytes: 10,
+		Sizer:    func(s string) int { return len(s) },
+	}, func(batch []string) error {
+		batches = append(batches, slices.Clone(batch))
+		return nil
+	})
+
+	for _, s := range []string{"aaaa", "bbbb", "cccc", "dddddddddddd", "ee"} {
+		buf.Push(s)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Run(ctx)
+
+	want := [][]string{{"aaaa", "bbbb"}, {"cccc"}, {"dddddddddddd"}, {"ee"}}
+	if !slices.EqualFunc(batches, want, slices.Equal) {
+		t.Errorf("Batches = %v, want %v", batches, want)
+	}
+}
+
+func TestBatcherMaxLatency(t *testing.T) {
+	buf := grin.New[int](64)
+	flushed := make(chan []int, 16)
+	b := grin.NewBatcher[int](buf, grin.BatcherConfig[int]{
+		MaxSize:    100,
+		MaxLatency: 5 * time.Millisecond,
+	}, func(batch []int) error {
+		flushed <- slices.Clone(batch)
+		return nil
+	})
+	stop := runBatcher(t, b)
+	defer stop()
+
+	start := time.Now()
+	buf.Push(1)
+	buf.Push(2)
+
+	select {
+	case batch := <-flushed:
+		if !slices.Equal(batch, []int{1, 2}) {
+			t.Errorf("Batch = %v, want [1 2]", batch)
+		}
+		if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+			t.Errorf("Batch flushed after %v, want at least 5ms", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test timed out - batch was not flushed after MaxLatency")
+	}
+}
+
+func TestBatcherFlushOnClose(t *testing.T) {
+	buf := grin.New[int](64)
+	var got []int
+	b := grin.NewBatcher[int](buf, grin.BatcherConfig[int]{MaxSize: 100}, func(batch []int) error {
+		got = append(got, batch...)
+		return nil
+	})
+	stop := runBatcher(t, b)
+
+	for i := 0; i < 10; i++ {
+		buf.Push(i)
+	}
+	stop()
+
+	if len(got) != 10 {
+		t.Errorf("Flushed %v on close, want all 10 items", got)
+	}
+}
+
+func TestBatcherFlushError(t *testing.T) {
+	boom := errors.New("boom")
+	buf := grin.New[int](64)
+	b := grin.NewBatcher[int](buf, grin.BatcherConfig[int]{MaxSize: 2}, func([]int) error {
+		return boom
+	})
+
+	buf.Push(1)
+	buf.Push(2)
+	if err := b.Run(context.Background()); !errors.Is(err, boom) {
+		t.Errorf("Run() error = %v, want %v", err, boom)
+	}
+}
+
+func TestBatcherNoAllocs(t *testing.T) {
+	buf := grin.New[int](64)
+	flushed := make(chan struct{})
+	b := grin.NewBatcher[int](buf, grin.BatcherConfig[int]{
+		MaxSize:    8,
+		MaxLatency: time.Hour,
+	}, func([]int) error {
+		flushed <- struct{}{}
+		return nil
+	})
+	stop := runBatcher(t, b)
+	defer stop()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < 8; i++ {
+			buf.Push(i)
+		}
+		<-flushed
+	})
+	if allocs != 0 {
+		t.Errorf("Allocs per batch = %v, want 0", allocs)
+	}
+}
+
+func TestBatcherInvalidConfig(t *testing.T) {
+	buf := grin.New[int](8)
+	for name, cfg := range map[string]grin.BatcherConfig[int]{
+		"no size":  {},
+		"no sizer": {MaxSize: 1, MaxBytes: 10},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("NewBatcher() did not panic")
+				}
+			}()
+			grin.NewBatcher[int](buf, cfg, func([]int) error { return nil })
+		})
+	}
+}
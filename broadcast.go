@@ -0,0 +1,234 @@
+package grin
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Broadcast is a single producer ring buffer read in full by any number of
+// independent readers, each with its own padded cursor. Items are not cleared
+// when read, every reader sees every item pushed after it joined.
+//
+// With FullReject the producer is held back by the slowest reader and Push
+// returns false until it catches up. With FullDropOldest the producer never waits,
+// it overwrites the oldest item instead and the readers that had yet to read it
+// skip ahead and record the items they missed in Lagged.
+type Broadcast[T any] struct {
+	store     []T
+	mask      uint64
+	overwrite bool
+	mu        sync.Mutex          // Guards joining
+	joining   []*Reader[T]        // Readers waiting for the producer to set their cursor
+	pending   atomic.Bool         // Whether joining is not empty
+	_         [cacheLineSize]byte // Do not remove
+
+	tail atomic.Uint64           // Owned by the producer, readers must use atomic operations to read
+	_    [cacheLineSize - 8]byte // Do not remove
+
+	readers []*Reader[T]        // Owned by the producer
+	slowest uint64              // Owned by the producer, lower bound on every reader's cursor
+	_       [cacheLineSize]byte // Do not remove
+}
+
+// Reader is one consumer of a Broadcast. It is only safe to use from a single
+// goroutine, but each Reader may be used from a different one.
+type Reader[T any] struct {
+	_ [cacheLineSize]byte // Do not remove
+
+	b       *Broadcast[T]
+	joined  atomic.Bool
+	left    atomic.Bool
+	cursor  atomic.Uint64       // Next item to read, advanced by the producer when overwriting
+	reading atomic.Uint64       // Item being read plus one, zero when idle
+	lagged  atomic.Uint64       // Items overwritten before they were read
+	_       [cacheLineSize]byte // Do not remove
+}
+
+// NewBroadcast creates a new broadcast ring buffer with the specified size.
+// Size must be a power of 2 and policy either FullReject or FullDropOldest,
+// otherwise it panics.
+func NewBroadcast[T any](size int, policy FullPolicy) *Broadcast[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+	if policy != FullReject && policy != FullDropOldest {
+		panic("broadcast only supports FullReject and FullDropOldest")
+	}
+
+	return &Broadcast[T]{
+		store:     make([]T, size),
+		mask:      uint64(size) - 1,
+		overwrite: policy == FullDropOldest,
+	}
+}
+
+// Join adds a new reader. The reader starts at the producer's tail as of its next
+// Push, and sees nothing pushed before that.
+//
+// Safe to call from any goroutine.
+func (b *Broadcast[T]) Join() *Reader[T] {
+	r := &Reader[T]{b: b}
+
+	b.mu.Lock()
+	b.joining = append(b.joining, r)
+	b.pending.Store(true)
+	b.mu.Unlock()
+
+	return r
+}
+
+// Push adds an item for every reader to read.
+// Returns false if the slowest reader has yet to make room (non-blocking), with
+// FullDropOldest it always returns true.
+//
+// Only safe to call from a single producer goroutine.
+func (b *Broadcast[T]) Push(t T) bool {
+	tail := b.tail.Load()
+	if b.pending.Load() {
+		b.join(tail)
+	}
+
+	size := uint64(len(b.store))
+	if b.overwrite {
+		// A reader at oldest+1 may still be copying out the slot we are about to write
+		if tail >= size && tail+1-b.slowest >= size {
+			b.overwriteOldest(tail - size)
+		}
+	} else if tail-b.slowest == size {
+		b.slowest = b.minCursor(tail)
+		if tail-b.slowest == size {
+			return false
+		}
+	}
+
+	b.store[tail&b.mask] = t
+	b.tail.Store(tail + 1)
+	return true
+}
+
+// join starts every joining reader at tail.
+func (b *Broadcast[T]) join(tail uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, r := range b.joining {
+		r.cursor.Store(tail)
+		r.joined.Store(true)
+		b.readers = append(b.readers, r)
+	}
+
+	b.joining = b.joining[:0]
+	b.pending.Store(false)
+}
+
+// minCursor drops readers that have left and returns the slowest cursor, or tail
+// if there are no readers.
+func (b *Broadcast[T]) minCursor(tail uint64) uint64 {
+	slowest := tail
+	live := b.readers[:0]
+	for _, r := range b.readers {
+		if r.left.Load() {
+			continue
+		}
+
+		live = append(live, r)
+		if c := r.cursor.Load(); c < slowest {
+			slowest = c
+		}
+	}
+
+	clear(b.readers[len(live):])
+	b.readers = live
+	return slowest
+}
+
+// overwriteOldest moves every reader that has yet to read oldest past it, and waits
+// for any reader still copying it out of its slot.
+func (b *Broadcast[T]) overwriteOldest(oldest uint64) {
+	for _, r := range b.readers {
+		if c := r.cursor.Load(); c == oldest && r.cursor.CompareAndSwap(c, c+1) {
+			r.lagged.Add(1)
+		}
+
+		for r.reading.Load() == oldest+1 {
+			runtime.Gosched()
+		}
+	}
+
+	b.slowest = b.minCursor(b.tail.Load())
+}
+
+// Cap returns the total capacity of the ring buffer.
+func (b *Broadcast[T]) Cap() int {
+	return len(b.store)
+}
+
+// Pop removes and returns the reader's next item.
+// Returns (zero value, false) if the reader has read everything (non-blocking).
+//
+// Only safe to call from the reader's goroutine.
+func (r *Reader[T]) Pop() (T, bool) {
+	t, _, ok := r.PopSeq()
+	return t, ok
+}
+
+// PopSeq removes and returns the reader's next item and its sequence number. With
+// FullDropOldest a jump in sequence numbers tells the reader which items it missed.
+// Returns (zero value, 0, false) if the reader has read everything (non-blocking).
+//
+// Only safe to call from the reader's goroutine.
+func (r *Reader[T]) PopSeq() (T, uint64, bool) {
+	var zero T
+	if !r.joined.Load() {
+		return zero, 0, false
+	}
+
+	b := r.b
+	for {
+		cursor := r.cursor.Load()
+		if cursor == b.tail.Load() {
+			return zero, 0, false
+		}
+
+		if !b.overwrite {
+			val := b.store[cursor&b.mask]
+			r.cursor.Store(cursor + 1)
+			return val, cursor, true
+		}
+
+		r.reading.Store(cursor + 1)
+		if !r.cursor.CompareAndSwap(cursor, cursor+1) {
+			// The producer overwrote this item, try the next one
+			r.reading.Store(0)
+			continue
+		}
+
+		val := b.store[cursor&b.mask]
+		r.reading.Store(0)
+		return val, cursor, true
+	}
+}
+
+// Len returns the number of items the reader has yet to read.
+func (r *Reader[T]) Len() int {
+	if !r.joined.Load() {
+		return 0
+	}
+
+	cursor := r.cursor.Load()
+	tail := r.b.tail.Load()
+	return int(tail - cursor)
+}
+
+// Lagged returns the number of items that were overwritten before the reader read
+// them. It is always zero with FullReject.
+func (r *Reader[T]) Lagged() uint64 {
+	return r.lagged.Load()
+}
+
+// Leave removes the reader, the producer no longer waits for it. The reader must
+// not be used afterwards.
+func (r *Reader[T]) Leave() {
+	r.left.Store(true)
+}
@@ -0,0 +1,165 @@
+package grin_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestBroadcastEveryReaderSeesEverything(t *testing.T) {
+	b := grin.NewBroadcast[int](8, grin.FullReject)
+	readers := []*grin.Reader[int]{b.Join(), b.Join(), b.Join()}
+
+	for i := 0; i < 8; i++ {
+		if !b.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+
+	for n, r := range readers {
+		if r.Len() != 8 {
+			t.Errorf("reader %d: Len() = %d, want 8", n, r.Len())
+		}
+		for i := 0; i < 8; i++ {
+			if got, ok := r.Pop(); !ok || got != i {
+				t.Errorf("reader %d: Pop() = (%d, %v), want (%d, true)", n, got, ok, i)
+			}
+		}
+		if got, ok := r.Pop(); ok {
+			t.Errorf("reader %d: Pop() when caught up = (%d, %v), want (0, false)", n, got, ok)
+		}
+	}
+}
+
+func TestBroadcastSlowestReaderBlocks(t *testing.T) {
+	b := grin.NewBroadcast[int](4, grin.FullReject)
+	fast, slow := b.Join(), b.Join()
+
+	for i := 0; i < 4; i++ {
+		b.Push(i)
+		fast.Pop()
+	}
+
+	if b.Push(4) {
+		t.Fatal("Push(4) succeeded while the slowest reader is 4 items behind")
+	}
+
+	slow.Pop()
+	if !b.Push(4) {
+		t.Fatal("Push(4) failed after the slowest reader made room")
+	}
+
+	slow.Leave()
+	for i := 5; i < 8; i++ {
+		if !b.Push(i) {
+			t.Fatalf("Push(%d) failed after the slowest reader left", i)
+		}
+	}
+}
+
+func TestBroadcastJoinAtTail(t *testing.T) {
+	b := grin.NewBroadcast[int](8, grin.FullReject)
+	first := b.Join()
+
+	b.Push(1)
+	late := b.Join()
+
+	if got, ok := late.Pop(); ok {
+		t.Errorf("Pop() before the next Push = (%d, %v), want (0, false)", got, ok)
+	}
+
+	b.Push(2)
+
+	if got, ok := late.Pop(); !ok || got != 2 {
+		t.Errorf("late reader: Pop() = (%d, %v), want (2, true)", got, ok)
+	}
+	for want := 1; want <= 2; want++ {
+		if got, ok := first.Pop(); !ok || got != want {
+			t.Errorf("first reader: Pop() = (%d, %v), want (%d, true)", got, ok, want)
+		}
+	}
+}
+
+func TestBroadcastDropOldestLagged(t *testing.T) {
+	b := grin.NewBroadcast[int](4, grin.FullDropOldest)
+	r := b.Join()
+
+	for i := 0; i < 10; i++ {
+		if !b.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+
+	if r.Lagged() != 6 {
+		t.Errorf("Lagged() = %d, want 6", r.Lagged())
+	}
+	for i := 6; i < 10; i++ {
+		if got, ok := r.Pop(); !ok || got != i {
+			t.Errorf("Pop() = (%d, %v), want (%d, true)", got, ok, i)
+		}
+	}
+}
+
+func TestBroadcastInvalidPolicy(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("NewBroadcast with FullSpillMemory should panic")
+		}
+	}()
+
+	grin.NewBroadcast[int](8, grin.FullSpillMemory)
+}
+
+func TestConcurrentBroadcast(t *testing.T) {
+	for _, policy := range []grin.FullPolicy{grin.FullReject, grin.FullDropOldest} {
+		b := grin.NewBroadcast[uint64](64, policy)
+		const numItems = 50000
+		const numReaders = 4
+
+		var wg sync.WaitGroup
+		for i := 0; i < numReaders; i++ {
+			r := b.Join()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer r.Leave()
+
+				var last uint64
+				for last != numItems {
+					val, ok := r.Pop()
+					if !ok {
+						runtime.Gosched()
+						continue
+					}
+					if val != last+1 && (policy == grin.FullReject || val <= last) {
+						t.Errorf("policy %d: got %d after %d", policy, val, last)
+					}
+					last = val
+				}
+			}()
+		}
+
+		go func() {
+			for i := uint64(1); i <= numItems; i++ {
+				for !b.Push(i) {
+					runtime.Gosched()
+				}
+			}
+		}()
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("policy %d: test timed out - possible deadlock", policy)
+		}
+	}
+}
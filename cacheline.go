@@ -0,0 +1,6 @@
+//go:build !(arm64 || ppc64 || ppc64le || s390x)
+
+package grin
+
+// cacheLineSize is the distance kept between fields written by different goroutines.
+const cacheLineSize = 64
@@ -0,0 +1,7 @@
+//go:build arm64 || ppc64 || ppc64le
+
+package grin
+
+// cacheLineSize is 128 bytes on Apple M-series, several ARM64 server parts and
+// POWER, or where the adjacent line prefetcher pulls lines in pairs.
+const cacheLineSize = 128
@@ -0,0 +1,6 @@
+//go:build s390x
+
+package grin
+
+// cacheLineSize matches the 256 byte cache lines on IBM Z.
+const cacheLineSize = 256
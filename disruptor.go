@@ -0,0 +1,247 @@
+package grin
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+)
+
+// Sequence is a monotonic cursor padded onto its own cache line. Its value is the
+// number of items that have passed it, so the next item it will pass is Load().
+type Sequence struct {
+	_     [cacheLineSize]byte // Do not remove
+	value atomic.Uint64
+	_     [cacheLineSize - 8]byte // Do not remove
+}
+
+// Load returns the number of items that have passed the sequence.
+func (s *Sequence) Load() uint64 {
+	return s.value.Load()
+}
+
+// SequenceBarrier tracks one or more upstream sequences. Items are available to
+// the barrier's owner once every upstream sequence has passed them.
+type SequenceBarrier struct {
+	deps []*Sequence
+}
+
+// NewSequenceBarrier creates a barrier over the given upstream sequences.
+func NewSequenceBarrier(deps ...*Sequence) *SequenceBarrier {
+	if len(deps) == 0 {
+		panic("sequence barrier needs at least one dependency")
+	}
+
+	return &SequenceBarrier{deps: deps}
+}
+
+// Available returns the number of items every upstream sequence has passed.
+func (b *SequenceBarrier) Available() uint64 {
+	available := b.deps[0].Load()
+	for _, dep := range b.deps[1:] {
+		if v := dep.Load(); v < available {
+			available = v
+		}
+	}
+	return available
+}
+
+// WaitFor waits until every upstream sequence has passed seq and returns
+// Available, or returns ctx.Err() if ctx is done first.
+func (b *SequenceBarrier) WaitFor(ctx context.Context, seq uint64) (uint64, error) {
+	for {
+		if available := b.Available(); available > seq {
+			return available, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		default:
+			runtime.Gosched()
+		}
+	}
+}
+
+// Disruptor is a single producer ring buffer whose slots are processed in place
+// by a graph of stages, in the style of the LMAX Disruptor. Each stage reads the
+// slots its upstream stages have finished with, and the producer only reuses a
+// slot once every final stage has finished with it.
+//
+// Slots are preallocated and never cleared, the producer claims a slot and fills
+// it in place so that steady state processing doesn't allocate.
+type Disruptor[T any] struct {
+	store []T
+	mask  uint64
+	gate  *SequenceBarrier // Over the final stages, the producer waits on the slowest
+
+	cursor Sequence // Published by the producer
+
+	next  uint64              // Owned by the producer, next sequence to claim
+	gated uint64              // Owned by the producer, lower bound on the slowest final stage
+	_     [cacheLineSize]byte // Do not remove
+}
+
+// Stage is one consumer in a Disruptor's graph. It is only safe to use from a
+// single goroutine, but each Stage may be used from a different one.
+type Stage[T any] struct {
+	d          *Disruptor[T]
+	barrier    *SequenceBarrier
+	downstream int
+
+	seq Sequence // Published by the stage
+}
+
+// DisruptorBuilder declares the stages of a Disruptor.
+type DisruptorBuilder[T any] struct {
+	d      *Disruptor[T]
+	stages []*Stage[T]
+}
+
+// NewDisruptorBuilder starts building a Disruptor with the specified size.
+// Size must be a power of 2, otherwise it panics.
+func NewDisruptorBuilder[T any](size int) *DisruptorBuilder[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+
+	return &DisruptorBuilder[T]{
+		d: &Disruptor[T]{
+			store: make([]T, size),
+			mask:  uint64(size) - 1,
+		},
+	}
+}
+
+// Stage declares a stage that processes each item after all of the given stages
+// have, or straight after the producer publishes it if none are given. Stages
+// without a common upstream run in parallel.
+func (b *DisruptorBuilder[T]) Stage(after ...*Stage[T]) *Stage[T] {
+	s := &Stage[T]{d: b.d}
+
+	if len(after) == 0 {
+		s.barrier = NewSequenceBarrier(&b.d.cursor)
+	} else {
+		deps := make([]*Sequence, 0, len(after))
+		for _, up := range after {
+			if up.d != b.d {
+				panic("stage belongs to a different disruptor")
+			}
+			up.downstream++
+			deps = append(deps, &up.seq)
+		}
+		s.barrier = NewSequenceBarrier(deps...)
+	}
+
+	b.stages = append(b.stages, s)
+	return s
+}
+
+// Build returns the Disruptor. The producer is gated on every stage that no other
+// stage depends on. Panics if no stages were declared.
+func (b *DisruptorBuilder[T]) Build() *Disruptor[T] {
+	if len(b.stages) == 0 {
+		panic("disruptor needs at least one stage")
+	}
+
+	var final []*Sequence
+	for _, s := range b.stages {
+		if s.downstream == 0 {
+			final = append(final, &s.seq)
+		}
+	}
+
+	b.d.gate = NewSequenceBarrier(final...)
+	return b.d
+}
+
+// Claim reserves the next slot for the producer to fill in place. Returns false
+// if the slowest final stage has yet to finish with it (non-blocking). The slot
+// must be published with Publish before the next Claim.
+//
+// Only safe to call from a single producer goroutine.
+func (d *Disruptor[T]) Claim() (uint64, *T, bool) {
+	seq := d.next
+	if seq-d.gated == uint64(len(d.store)) {
+		d.gated = d.gate.Available()
+		if seq-d.gated == uint64(len(d.store)) {
+			return 0, nil, false
+		}
+	}
+
+	d.next++
+	return seq, &d.store[seq&d.mask], true
+}
+
+// Publish makes a claimed slot visible to the first stages.
+//
+// Only safe to call from a single producer goroutine.
+func (d *Disruptor[T]) Publish(seq uint64) {
+	d.cursor.value.Store(seq + 1)
+}
+
+// Push copies an item into the next slot and publishes it.
+// Returns false if the slowest final stage has yet to make room (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (d *Disruptor[T]) Push(t T) bool {
+	seq, slot, ok := d.Claim()
+	if !ok {
+		return false
+	}
+
+	*slot = t
+	d.Publish(seq)
+	return true
+}
+
+// Cursor returns the producer's sequence.
+func (d *Disruptor[T]) Cursor() *Sequence {
+	return &d.cursor
+}
+
+// Cap returns the total capacity of the ring buffer.
+func (d *Disruptor[T]) Cap() int {
+	return len(d.store)
+}
+
+// Sequence returns the stage's sequence, for use in custom barriers.
+func (s *Stage[T]) Sequence() *Sequence {
+	return &s.seq
+}
+
+// Barrier returns the barrier over the stage's upstream sequences.
+func (s *Stage[T]) Barrier() *SequenceBarrier {
+	return s.barrier
+}
+
+// Poll calls fn for every item the stage's upstream has finished with, in order,
+// then releases them downstream. Returns the number of items processed
+// (non-blocking).
+//
+// Only safe to call from the stage's goroutine.
+func (s *Stage[T]) Poll(fn func(seq uint64, t *T)) int {
+	next := s.seq.value.Load()
+	available := s.barrier.Available()
+
+	for seq := next; seq < available; seq++ {
+		fn(seq, &s.d.store[seq&s.d.mask])
+	}
+
+	if available > next {
+		s.seq.value.Store(available)
+	}
+	return int(available - next)
+}
+
+// Run calls Poll until ctx is done, waiting on the stage's barrier in between.
+//
+// Only safe to call from the stage's goroutine.
+func (s *Stage[T]) Run(ctx context.Context, fn func(seq uint64, t *T)) error {
+	for {
+		if _, err := s.barrier.WaitFor(ctx, s.seq.value.Load()); err != nil {
+			return err
+		}
+
+		s.Poll(fn)
+	}
+}
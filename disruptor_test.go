@@ -0,0 +1,173 @@
+package grin_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+type order struct {
+	ID         int
+	Journaled  bool
+	Replicated bool
+}
+
+func TestDisruptorStageOrdering(t *testing.T) {
+	b := grin.NewDisruptorBuilder[order](4)
+	journal := b.Stage()
+	logic := b.Stage(journal)
+	d := b.Build()
+
+	for i := 0; i < 4; i++ {
+		if !d.Push(order{ID: i}) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+
+	if d.Push(order{ID: 4}) {
+		t.Fatal("Push(4) succeeded before any stage released a slot")
+	}
+
+	if n := logic.Poll(func(uint64, *order) {}); n != 0 {
+		t.Fatalf("logic.Poll() processed %d items before journal, want 0", n)
+	}
+
+	n := journal.Poll(func(seq uint64, o *order) {
+		if int(seq) != o.ID {
+			t.Errorf("journal: seq %d holds order %d", seq, o.ID)
+		}
+		o.Journaled = true
+	})
+	if n != 4 {
+		t.Errorf("journal.Poll() = %d, want 4", n)
+	}
+
+	if d.Push(order{ID: 4}) {
+		t.Fatal("Push(4) succeeded before the final stage released a slot")
+	}
+
+	logic.Poll(func(seq uint64, o *order) {
+		if !o.Journaled {
+			t.Errorf("logic: order %d was not journaled", o.ID)
+		}
+	})
+
+	if !d.Push(order{ID: 4}) {
+		t.Fatal("Push(4) failed after the final stage released every slot")
+	}
+	if got := logic.Sequence().Load(); got != 4 {
+		t.Errorf("logic.Sequence().Load() = %d, want 4", got)
+	}
+}
+
+func TestDisruptorClaimInPlace(t *testing.T) {
+	b := grin.NewDisruptorBuilder[order](8)
+	s := b.Stage()
+	d := b.Build()
+
+	seq, slot, ok := d.Claim()
+	if !ok || seq != 0 {
+		t.Fatalf("Claim() = (%d, %v), want (0, true)", seq, ok)
+	}
+
+	slot.ID = 42
+	if n := s.Poll(func(uint64, *order) {}); n != 0 {
+		t.Fatalf("Poll() processed %d unpublished items", n)
+	}
+
+	d.Publish(seq)
+	s.Poll(func(_ uint64, o *order) {
+		if o.ID != 42 {
+			t.Errorf("Poll() saw order %d, want 42", o.ID)
+		}
+	})
+}
+
+func TestDisruptorZeroAllocs(t *testing.T) {
+	b := grin.NewDisruptorBuilder[order](64)
+	journal, replicate := b.Stage(), b.Stage()
+	logic := b.Stage(journal, replicate)
+	d := b.Build()
+
+	noop := func(uint64, *order) {}
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < 64; i++ {
+			d.Push(order{ID: i})
+		}
+		journal.Poll(noop)
+		replicate.Poll(noop)
+		logic.Poll(noop)
+	})
+	if allocs != 0 {
+		t.Errorf("Push/Poll allocated %v times per run, want 0", allocs)
+	}
+}
+
+func TestSequenceBarrierWaitForCancelled(t *testing.T) {
+	b := grin.NewDisruptorBuilder[int](8)
+	b.Stage()
+	d := b.Build()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	barrier := grin.NewSequenceBarrier(d.Cursor())
+	if _, err := barrier.WaitFor(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitFor() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConcurrentDisruptorDiamond(t *testing.T) {
+	b := grin.NewDisruptorBuilder[order](256)
+	journal, replicate := b.Stage(), b.Stage()
+	logic := b.Stage(journal, replicate)
+	d := b.Build()
+
+	const numItems = 100000
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	run := func(s *grin.Stage[order], fn func(uint64, *order)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Run(ctx, fn)
+		}()
+	}
+
+	run(journal, func(_ uint64, o *order) { o.Journaled = true })
+	run(replicate, func(_ uint64, o *order) { o.Replicated = true })
+
+	done := make(chan struct{})
+	run(logic, func(seq uint64, o *order) {
+		if int(seq) != o.ID || !o.Journaled || !o.Replicated {
+			t.Errorf("logic: seq %d saw %+v", seq, *o)
+		}
+		if o.ID == numItems-1 {
+			close(done)
+		}
+	})
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for !d.Push(order{ID: i}) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out - possible deadlock")
+	}
+
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,177 @@
+package grin
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// dropOldestRing is a SPSC ring buffer whose Push never fails. When it is full the
+// producer discards the oldest item to make room for the new one.
+//
+// Because both sides can advance head, the consumer claims a slot with a CAS before
+// reading it and advertises the claim in reading. The producer never overwrites a
+// slot that the consumer is still reading.
+type dropOldestRing[T any] struct {
+	store      []T
+	mask       uint64
+	clearOnPop bool
+	ready      *readiness
+	wait       WaitStrategy
+	_          [cacheLineSize]byte // Do not remove
+
+	head    atomic.Uint64           // Next slot to read, advanced by the consumer and by the producer when dropping
+	reading atomic.Uint64           // Slot being read by the consumer plus one, zero when idle
+	_       [cacheLineSize - 8]byte // Do not remove
+
+	tail    atomic.Uint64           // Owned by the producer, consumer must use atomic operations to read
+	dropped atomic.Uint64           // Owned by the producer
+	_       [cacheLineSize - 8]byte // Do not remove
+}
+
+var _ RingBuffer[int] = (*dropOldestRing[int])(nil)
+
+func newDropOldestRing[T any](size int, o options) *dropOldestRing[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+
+	ready := newReadiness()
+	if o.eventFD != nil {
+		ready.eventFD = o.eventFD
+		ready.watchReadable.Store(true)
+	}
+
+	return &dropOldestRing[T]{
+		store:      make([]T, size),
+		mask:       uint64(size) - 1,
+		clearOnPop: o.clearOnPop,
+		ready:      ready,
+		wait:       o.wait,
+	}
+}
+
+// Push adds an item to the ring buffer, dropping the oldest item if it is full.
+// Always returns true.
+//
+// Only safe to call from a single producer goroutine.
+func (b *dropOldestRing[T]) Push(t T) bool {
+	tail := b.tail.Load()
+	size := uint64(len(b.store))
+
+	if tail >= size {
+		// The slot we are about to write last held this item
+		oldest := tail - size
+		if b.head.Load() == oldest && b.head.CompareAndSwap(oldest, oldest+1) {
+			b.dropped.Add(1)
+		}
+
+		// Either the item was already popped, we just dropped it, or the consumer
+		// claimed it and may still be copying it out of the slot.
+		for b.reading.Load() == oldest+1 {
+			runtime.Gosched()
+		}
+	}
+
+	b.store[tail&b.mask] = t
+	b.tail.Store(tail + 1)
+
+	if b.ready.watchReadable.Load() && b.head.Load() == tail {
+		b.ready.becameReadable()
+	}
+	if b.wait != nil {
+		b.wait.Notify()
+	}
+	return true
+}
+
+// PushSeq adds an item to the ring buffer, dropping the oldest item if it is
+// full, and returns its sequence number. Always returns true.
+//
+// Only safe to call from a single producer goroutine.
+func (b *dropOldestRing[T]) PushSeq(t T) (uint64, bool) {
+	seq := b.tail.Load()
+	return seq, b.Push(t)
+}
+
+// Pop removes and returns an item from the ring buffer.
+// Returns (zero value, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (b *dropOldestRing[T]) Pop() (T, bool) {
+	t, _, ok := b.PopSeq()
+	return t, ok
+}
+
+// PopSeq removes and returns an item and its sequence number from the ring buffer.
+// Returns (zero value, 0, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (b *dropOldestRing[T]) PopSeq() (T, uint64, bool) {
+	for {
+		head := b.head.Load()
+		if head == b.tail.Load() {
+			var zero T
+			return zero, 0, false
+		}
+
+		b.reading.Store(head + 1)
+		if !b.head.CompareAndSwap(head, head+1) {
+			// The producer dropped this item, try the next one
+			b.reading.Store(0)
+			continue
+		}
+
+		slot := &b.store[head&b.mask]
+		val := *slot
+		if b.clearOnPop {
+			var zero T
+			*slot = zero
+		}
+
+		b.reading.Store(0)
+		if b.wait != nil {
+			b.wait.Notify()
+		}
+		return val, head, true
+	}
+}
+
+// Stats returns the head and tail along with the number of items dropped to make
+// room for newer ones. It is safe to call from any goroutine.
+func (b *dropOldestRing[T]) Stats() Stats {
+	return Stats{
+		Head:    b.head.Load(),
+		Tail:    b.tail.Load(),
+		Dropped: b.dropped.Load(),
+	}
+}
+
+func (b *dropOldestRing[T]) Cap() int {
+	return len(b.store)
+}
+
+func (b *dropOldestRing[T]) Len() int {
+	head := b.head.Load()
+	tail := b.tail.Load()
+	return int(tail - head)
+}
+
+func (b *dropOldestRing[T]) Available() int {
+	return b.Cap() - b.Len()
+}
+
+// Flush is a no-op, every push is published immediately.
+func (b *dropOldestRing[T]) Flush() {}
+
+// Readable returns a channel that fires when the buffer goes from empty to
+// non-empty. It may fire spuriously, so always Pop to check.
+//
+// Only safe to receive from a single consumer goroutine.
+func (b *dropOldestRing[T]) Readable() <-chan struct{} {
+	return b.ready.Readable()
+}
+
+// Writable returns a closed channel, Push never fails.
+func (b *dropOldestRing[T]) Writable() <-chan struct{} {
+	return closedChan
+}
@@ -0,0 +1,55 @@
+package grin
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// EventFD is a Linux eventfd that a ring signals when it goes from empty to
+// non-empty, so that it can be waited on with epoll alongside other file
+// descriptors. Pass it to New with WithEventFD.
+type EventFD struct {
+	fd int
+}
+
+// NewEventFD creates a new non-blocking eventfd.
+func NewEventFD() (*EventFD, error) {
+	fd, _, errno := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return &EventFD{fd: int(fd)}, nil
+}
+
+// Fd returns the file descriptor to register with epoll for EPOLLIN.
+func (e *EventFD) Fd() int {
+	return e.fd
+}
+
+// Ack resets the eventfd once the consumer has been woken. Call it before draining
+// the ring, a transition that happens while draining then signals again.
+//
+// Only safe to call from a single consumer goroutine.
+func (e *EventFD) Ack() error {
+	var buf [8]byte
+	_, err := syscall.Read(e.fd, buf[:])
+	if err == syscall.EAGAIN {
+		// Nothing was signalled
+		return nil
+	}
+	return err
+}
+
+// Close closes the file descriptor.
+func (e *EventFD) Close() error {
+	return syscall.Close(e.fd)
+}
+
+func (e *EventFD) signal() {
+	var buf [8]byte
+	binary.NativeEndian.PutUint64(buf[:], 1)
+
+	// The counter can't realistically overflow, so the write can't block or fail
+	_, _ = syscall.Write(e.fd, buf[:])
+}
@@ -0,0 +1,58 @@
+package grin_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestEventFDEpoll(t *testing.T) {
+	efd, err := grin.NewEventFD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer efd.Close()
+
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(epfd)
+
+	ev := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(efd.Fd())}
+	if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, efd.Fd(), &ev); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := grin.New[int](8, grin.WithEventFD(efd))
+	events := make([]syscall.EpollEvent, 1)
+
+	if n, _ := syscall.EpollWait(epfd, events, 0); n != 0 {
+		t.Fatal("eventfd is readable before any Push")
+	}
+
+	go buf.Push(1)
+
+	if n, err := syscall.EpollWait(epfd, events, 5000); err != nil || n != 1 {
+		t.Fatalf("EpollWait() = (%d, %v), want (1, nil)", n, err)
+	}
+	if err := efd.Ack(); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	if got, ok := buf.Pop(); !ok || got != 1 {
+		t.Errorf("Pop() = (%d, %v), want (1, true)", got, ok)
+	}
+
+	// Only empty to non-empty transitions signal
+	buf.Push(2)
+	efd.Ack()
+	buf.Push(3)
+	if n, _ := syscall.EpollWait(epfd, events, 0); n != 0 {
+		t.Error("eventfd signalled without an empty to non-empty transition")
+	}
+
+	if err := efd.Ack(); err != nil {
+		t.Errorf("Ack() with nothing signalled error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package grin
+
+// EventFD is a no-op outside of Linux, Fd returns -1 and the ring never signals it.
+type EventFD struct{}
+
+// NewEventFD returns a no-op EventFD.
+func NewEventFD() (*EventFD, error) {
+	return &EventFD{}, nil
+}
+
+// Fd returns -1, there is no file descriptor outside of Linux.
+func (e *EventFD) Fd() int {
+	return -1
+}
+
+// Ack is a no-op.
+func (e *EventFD) Ack() error {
+	return nil
+}
+
+// Close is a no-op.
+func (e *EventFD) Close() error {
+	return nil
+}
+
+func (e *EventFD) signal() {}
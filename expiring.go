@@ -0,0 +1,165 @@
+package grin
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// epoch anchors deadlines to the monotonic clock, so that they are immune to wall
+// clock changes and fit in an int64.
+var epoch = time.Now()
+
+// monotonic returns the nanoseconds elapsed since epoch, which are always > 0.
+func monotonic() int64 {
+	return int64(time.Since(epoch)) + 1
+}
+
+type expiringItem[T any] struct {
+	t        T
+	deadline int64 // Nanoseconds since epoch, 0 for never
+}
+
+// Expiring is a SPSC ring buffer whose items carry a deadline, set explicitly with
+// PushDeadline or by WithTTL. Pop skips and counts items whose deadline has
+// passed, so a consumer that falls behind never processes stale data.
+type Expiring[T any] struct {
+	ring     RingBuffer[expiringItem[T]]
+	ttl      int64
+	onExpire func(T)
+	_        [cacheLineSize]byte // Do not remove
+
+	expired atomic.Uint64           // Incremented by the consumer
+	_       [cacheLineSize - 8]byte // Do not remove
+}
+
+var _ RingBuffer[int] = (*Expiring[int])(nil)
+
+// NewExpiring creates a new ring buffer with the specified size, which must be a
+// power of 2. onExpire, if not nil, is called by Pop with every item it skips.
+func NewExpiring[T any](size int, onExpire func(T), opts ...Option) *Expiring[T] {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Expiring[T]{
+		ring:     New[expiringItem[T]](size, opts...),
+		ttl:      int64(o.ttl),
+		onExpire: onExpire,
+	}
+}
+
+// Push adds an item that expires after the TTL given by WithTTL, if any.
+// Returns false if the buffer is full (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (e *Expiring[T]) Push(t T) bool {
+	_, ok := e.PushSeq(t)
+	return ok
+}
+
+// PushSeq adds an item that expires after the TTL given by WithTTL, if any, and
+// returns its sequence number.
+// Returns false if the buffer is full (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (e *Expiring[T]) PushSeq(t T) (uint64, bool) {
+	var deadline int64
+	if e.ttl > 0 {
+		deadline = monotonic() + e.ttl
+	}
+	return e.ring.PushSeq(expiringItem[T]{t: t, deadline: deadline})
+}
+
+// PushDeadline adds an item that expires at deadline, ignoring WithTTL.
+// Returns false if the buffer is full (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (e *Expiring[T]) PushDeadline(t T, deadline time.Time) bool {
+	_, ok := e.PushDeadlineSeq(t, deadline)
+	return ok
+}
+
+// PushDeadlineSeq adds an item that expires at deadline, ignoring WithTTL, and
+// returns its sequence number.
+// Returns false if the buffer is full (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (e *Expiring[T]) PushDeadlineSeq(t T, deadline time.Time) (uint64, bool) {
+	return e.ring.PushSeq(expiringItem[T]{t: t, deadline: max(int64(deadline.Sub(epoch))+1, 1)})
+}
+
+// Pop removes and returns the oldest item that has not expired, discarding the
+// expired items in front of it.
+// Returns (zero value, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (e *Expiring[T]) Pop() (T, bool) {
+	t, _, ok := e.PopSeq()
+	return t, ok
+}
+
+// PopSeq removes and returns the oldest item that has not expired and its sequence
+// number, discarding the expired items in front of it.
+// Returns (zero value, 0, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (e *Expiring[T]) PopSeq() (T, uint64, bool) {
+	var now int64
+	for {
+		item, seq, ok := e.ring.PopSeq()
+		if !ok || item.deadline == 0 {
+			return item.t, seq, ok
+		}
+
+		// Read the clock at most once per Pop
+		if now == 0 {
+			now = monotonic()
+		}
+		if item.deadline > now {
+			return item.t, seq, true
+		}
+
+		e.expired.Add(1)
+		if e.onExpire != nil {
+			e.onExpire(item.t)
+		}
+	}
+}
+
+// Expired returns how many items Pop has discarded because they expired.
+func (e *Expiring[T]) Expired() uint64 {
+	return e.expired.Load()
+}
+
+// Stats reports expired items as dropped. It is safe to call from any goroutine.
+func (e *Expiring[T]) Stats() Stats {
+	s := e.ring.Stats()
+	s.Dropped = e.expired.Load()
+	return s
+}
+
+func (e *Expiring[T]) Cap() int {
+	return e.ring.Cap()
+}
+
+// Len includes expired items that Pop has not discarded yet.
+func (e *Expiring[T]) Len() int {
+	return e.ring.Len()
+}
+
+func (e *Expiring[T]) Available() int {
+	return e.ring.Available()
+}
+
+func (e *Expiring[T]) Flush() {
+	e.ring.Flush()
+}
+
+func (e *Expiring[T]) Readable() <-chan struct{} {
+	return e.ring.Readable()
+}
+
+func (e *Expiring[T]) Writable() <-chan struct{} {
+	return e.ring.Writable()
+}
@@ -0,0 +1,115 @@
+package grin_test
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestExpiringTTL(t *testing.T) {
+	var expired []int
+	buf := grin.NewExpiring[int](8, func(i int) { expired = append(expired, i) }, grin.WithTTL(time.Millisecond))
+
+	buf.Push(1)
+	buf.Push(2)
+	time.Sleep(5 * time.Millisecond)
+	buf.Push(3)
+
+	if v, ok := buf.Pop(); !ok || v != 3 {
+		t.Errorf("Pop() = (%d, %v), want (3, true)", v, ok)
+	}
+	if !slices.Equal(expired, []int{1, 2}) {
+		t.Errorf("Expired items = %v, want [1 2]", expired)
+	}
+	if buf.Expired() != 2 {
+		t.Errorf("Expired() = %d, want 2", buf.Expired())
+	}
+	if _, ok := buf.Pop(); ok {
+		t.Error("Pop() on empty buffer should return false")
+	}
+}
+
+func TestExpiringNoTTL(t *testing.T) {
+	buf := grin.NewExpiring[int](8, nil)
+
+	buf.Push(1)
+	time.Sleep(time.Millisecond)
+
+	if v, ok := buf.Pop(); !ok || v != 1 {
+		t.Errorf("Pop() = (%d, %v), want (1, true)", v, ok)
+	}
+	if buf.Expired() != 0 {
+		t.Errorf("Expired() = %d, want 0", buf.Expired())
+	}
+}
+
+func TestExpiringPushDeadline(t *testing.T) {
+	buf := grin.NewExpiring[string](8, nil, grin.WithTTL(time.Nanosecond))
+
+	now := time.Now()
+	buf.PushDeadline("past", now.Add(-time.Second))
+	buf.PushDeadline("wall clock past", time.Unix(0, 0))
+	buf.PushDeadline("future", now.Add(time.Hour))
+
+	if v, ok := buf.Pop(); !ok || v != "future" {
+		t.Errorf("Pop() = (%q, %v), want (%q, true)", v, ok, "future")
+	}
+	if buf.Expired() != 2 {
+		t.Errorf("Expired() = %d, want 2", buf.Expired())
+	}
+}
+
+func TestExpiringAllExpired(t *testing.T) {
+	buf := grin.NewExpiring[int](4, nil)
+	for i := 0; i < 4; i++ {
+		buf.PushDeadline(i, time.Now().Add(-time.Millisecond))
+	}
+	if buf.Push(4) {
+		t.Error("Push() on full buffer should return false")
+	}
+
+	if _, ok := buf.Pop(); ok {
+		t.Error("Pop() with only expired items should return false")
+	}
+	if buf.Expired() != 4 || buf.Len() != 0 {
+		t.Errorf("Expired() = %d, Len() = %d, want 4 and 0", buf.Expired(), buf.Len())
+	}
+}
+
+func TestExpiringConcurrent(t *testing.T) {
+	const n = 100000
+	buf := grin.NewExpiring[int](1024, nil, grin.WithTTL(time.Hour))
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < n; i++ {
+			for !buf.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	go func() {
+		for i := 0; i < n; i++ {
+			var v int
+			var ok bool
+			for v, ok = buf.Pop(); !ok; v, ok = buf.Pop() {
+				runtime.Gosched()
+			}
+			if v != i {
+				t.Errorf("Pop() = %d, want %d", v, i)
+				break
+			}
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+}
@@ -0,0 +1,322 @@
+package grin
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// FanInLane is one SPSC lane of a FanIn, owned by a single producer goroutine.
+type FanInLane[T any] struct {
+	fanIn  *FanIn[T]
+	ring   RingBuffer[T]
+	weight int
+	index  int
+	word   *atomic.Uint64 // Summary bitmap word holding this lane's bit
+	bit    uint64
+	_      [cacheLineSize]byte // Do not remove
+
+	// Set by the producer when the lane becomes non-empty and cleared by the consumer
+	// when it finds the lane empty, so the producer only touches the bitmap on
+	// transitions
+	active atomic.Bool
+	closed atomic.Bool
+	_      [cacheLineSize - 2]byte // Do not remove
+}
+
+// fanInLanes is an immutable snapshot of the lanes of a FanIn, replaced whenever a
+// lane is added or removed. Bitmap words are shared between snapshots so that no
+// bit set by a producer is lost.
+type fanInLanes[T any] struct {
+	lanes []*FanInLane[T] // nil for removed lanes
+	words []*atomic.Uint64
+}
+
+// FanIn merges many SPSC lanes, each with its own producer, into one consumer. It
+// avoids the contention of a multi-producer queue by giving every producer a lane.
+//
+// The consumer serves non-empty lanes in turn, draining up to a lane's weight items
+// before moving to the next, so all weights of 1 is plain round-robin. Lanes that
+// are non-empty are tracked in a summary bitmap, so the consumer skips empty lanes
+// without looking at them.
+type FanIn[T any] struct {
+	opts  []Option
+	ready *readiness
+	mu    sync.Mutex // Serialises adding and removing lanes
+	free  []int      // Indexes of removed lanes, guarded by mu
+	lanes atomic.Pointer[fanInLanes[T]]
+	_     [cacheLineSize]byte // Do not remove
+
+	current *FanInLane[T] // Owned by the consumer, lane being drained
+	credit  int           // Owned by the consumer, pops left in the current lane's turn
+	next    int           // Owned by the consumer, index to look for the next lane from
+}
+
+// NewFanIn creates an empty fan-in. The options are used for every lane's ring.
+func NewFanIn[T any](opts ...Option) *FanIn[T] {
+	f := &FanIn[T]{
+		opts:  opts,
+		ready: newReadiness(),
+	}
+	f.lanes.Store(&fanInLanes[T]{})
+	return f
+}
+
+// Add registers a new lane with the given size, which must be a power of 2, and
+// weight, the most items popped from it per turn. It is safe to call from any
+// goroutine, and the lane is handed to a single producer goroutine.
+func (f *FanIn[T]) Add(size, weight int) *FanInLane[T] {
+	if weight < 1 {
+		panic("lane weight must be at least 1")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old := f.lanes.Load()
+	snap := &fanInLanes[T]{
+		lanes: append([]*FanInLane[T](nil), old.lanes...),
+		words: old.words,
+	}
+
+	index := len(snap.lanes)
+	if n := len(f.free); n > 0 {
+		index = f.free[n-1]
+		f.free = f.free[:n-1]
+	} else {
+		snap.lanes = append(snap.lanes, nil)
+		if index/64 == len(snap.words) {
+			snap.words = append(snap.words[:len(snap.words):len(snap.words)], new(atomic.Uint64))
+		}
+	}
+
+	l := &FanInLane[T]{
+		fanIn:  f,
+		ring:   New[T](size, f.opts...),
+		weight: weight,
+		index:  index,
+		word:   snap.words[index/64],
+		bit:    1 << (index % 64),
+	}
+	snap.lanes[index] = l
+	f.lanes.Store(snap)
+	return l
+}
+
+// remove drops a closed and drained lane. Called by the consumer.
+func (f *FanIn[T]) remove(l *FanInLane[T]) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Close may have set the bit again after idle cleared it, but it holds mu while
+	// it does so. Clear it for good so that Pop never sees it for a removed lane.
+	l.word.And(^l.bit)
+
+	old := f.lanes.Load()
+	snap := &fanInLanes[T]{
+		lanes: append([]*FanInLane[T](nil), old.lanes...),
+		words: old.words,
+	}
+	snap.lanes[l.index] = nil
+	f.free = append(f.free, l.index)
+	f.lanes.Store(snap)
+}
+
+// Push adds an item to the lane.
+// Returns false if the lane is full (non-blocking).
+//
+// Only safe to call from the lane's producer goroutine.
+func (l *FanInLane[T]) Push(t T) bool {
+	if !l.ring.Push(t) {
+		return false
+	}
+
+	if !l.active.Load() {
+		l.activate()
+	}
+	return true
+}
+
+// activate marks the lane as non-empty in the summary bitmap.
+func (l *FanInLane[T]) activate() {
+	l.active.Store(true)
+	l.word.Or(l.bit)
+
+	if r := l.fanIn.ready; r.watchReadable.Load() {
+		r.becameReadable()
+	}
+}
+
+// Flush publishes any pending pushes to the consumer.
+// It is a no-op unless the lane was created with WithPublishEvery.
+//
+// Only safe to call from the lane's producer goroutine.
+func (l *FanInLane[T]) Flush() {
+	l.ring.Flush()
+	if !l.active.Load() && l.ring.Len() > 0 {
+		l.activate()
+	}
+}
+
+// Close removes the lane from the FanIn once the consumer has drained it. The lane
+// must not be pushed to after Close.
+//
+// Only safe to call from the lane's producer goroutine.
+func (l *FanInLane[T]) Close() {
+	l.ring.Flush()
+
+	f := l.fanIn
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Make sure the consumer visits the lane to remove it
+	l.closed.Store(true)
+	l.activate()
+}
+
+func (l *FanInLane[T]) Cap() int {
+	return l.ring.Cap()
+}
+
+func (l *FanInLane[T]) Len() int {
+	return l.ring.Len()
+}
+
+func (l *FanInLane[T]) Available() int {
+	return l.ring.Available()
+}
+
+// Pop removes and returns an item from the next non-empty lane.
+// Returns (zero value, false) if every lane is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (f *FanIn[T]) Pop() (T, bool) {
+	if l := f.current; l != nil {
+		if val, ok := l.ring.Pop(); ok {
+			if f.credit--; f.credit == 0 {
+				f.current = nil
+			}
+			return val, true
+		}
+		f.current = nil
+		f.idle(l)
+	}
+
+	snap := f.lanes.Load()
+	for {
+		i, ok := snap.nextActive(f.next)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+
+		l := snap.lanes[i]
+		if l == nil {
+			// Removed lanes have their bit cleared by idle, so the bit belongs to a lane
+			// added in its place since we took the snapshot
+			snap = f.lanes.Load()
+			continue
+		}
+		f.next = i + 1
+
+		if val, ok := l.ring.Pop(); ok {
+			if l.weight > 1 {
+				f.current, f.credit = l, l.weight-1
+			}
+			return val, true
+		}
+		f.idle(l)
+	}
+}
+
+// PopBatch pops up to len(dst) items into dst and returns how many it popped.
+//
+// Only safe to call from a single consumer goroutine.
+func (f *FanIn[T]) PopBatch(dst []T) int {
+	for i := range dst {
+		val, ok := f.Pop()
+		if !ok {
+			return i
+		}
+		dst[i] = val
+	}
+	return len(dst)
+}
+
+// idle clears the lane from the summary bitmap after the consumer found it empty,
+// and removes it if it has been closed.
+func (f *FanIn[T]) idle(l *FanInLane[T]) {
+	l.active.Store(false)
+	l.word.And(^l.bit)
+
+	// Check again after clearing active, the producer only sets it if it sees it cleared
+	if l.ring.Len() > 0 {
+		l.activate()
+		return
+	}
+
+	if l.closed.Load() && l.ring.Len() == 0 {
+		f.remove(l)
+	}
+}
+
+// nextActive returns the index of the first lane at or after from, wrapping
+// around, whose bit is set.
+func (s *fanInLanes[T]) nextActive(from int) (int, bool) {
+	n := len(s.lanes)
+	if n == 0 {
+		return 0, false
+	}
+	if from >= n {
+		from = 0
+	}
+
+	// Look from the starting bit onwards, then wrap around to the bits before it
+	start := from / 64
+	for w := 0; w <= len(s.words); w++ {
+		i := (start + w) % len(s.words)
+		word := s.words[i].Load()
+		if w == 0 {
+			word &= ^uint64(0) << (from % 64)
+		} else if w == len(s.words) {
+			word &= (uint64(1) << (from % 64)) - 1
+		}
+
+		if word != 0 {
+			if index := i*64 + bits.TrailingZeros64(word); index < n {
+				return index, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Len returns the number of items across all lanes.
+func (f *FanIn[T]) Len() int {
+	n := 0
+	for _, l := range f.lanes.Load().lanes {
+		if l != nil {
+			n += l.ring.Len()
+		}
+	}
+	return n
+}
+
+// Lanes returns the number of registered lanes that have not been removed.
+func (f *FanIn[T]) Lanes() int {
+	n := 0
+	for _, l := range f.lanes.Load().lanes {
+		if l != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Readable returns a channel that fires when any lane goes from empty to
+// non-empty. It may fire spuriously, so always Pop to check.
+//
+// Only safe to receive from a single consumer goroutine.
+func (f *FanIn[T]) Readable() <-chan struct{} {
+	return f.ready.Readable()
+}
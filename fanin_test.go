@@ -0,0 +1,250 @@
+package grin_test
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestFanInRoundRobin(t *testing.T) {
+	f := grin.NewFanIn[string]()
+	a := f.Add(8, 1)
+	b := f.Add(8, 1)
+	c := f.Add(8, 1)
+
+	for _, s := range []string{"a1", "a2", "a3"} {
+		a.Push(s)
+	}
+	b.Push("b1")
+	for _, s := range []string{"c1", "c2"} {
+		c.Push(s)
+	}
+
+	var got []string
+	for {
+		s, ok := f.Pop()
+		if !ok {
+			break
+		}
+		got = append(got, s)
+	}
+
+	want := []string{"a1", "b1", "c1", "a2", "c2", "a3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Popped %v, want %v", got, want)
+	}
+}
+
+func TestFanInWeighted(t *testing.T) {
+	f := grin.NewFanIn[int]()
+	heavy := f.Add(16, 3)
+	light := f.Add(16, 1)
+
+	for i := 0; i < 6; i++ {
+		heavy.Push(i)
+		light.Push(100 + i)
+	}
+
+	got := make([]int, 8)
+	if n := f.PopBatch(got); n != 8 {
+		t.Fatalf("PopBatch() = %d, want 8", n)
+	}
+
+	want := []int{0, 1, 2, 100, 3, 4, 5, 101}
+	if !slices.Equal(got, want) {
+		t.Errorf("Popped %v, want %v", got, want)
+	}
+}
+
+func TestFanInManyLanes(t *testing.T) {
+	f := grin.NewFanIn[int]()
+	var lanes []*grin.FanInLane[int]
+	for i := 0; i < 200; i++ {
+		lanes = append(lanes, f.Add(2, 1))
+	}
+
+	// Only a few lanes across different bitmap words have items
+	active := []int{3, 64, 130, 199}
+	for _, i := range active {
+		lanes[i].Push(i)
+	}
+
+	var got []int
+	for {
+		v, ok := f.Pop()
+		if !ok {
+			break
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, active) {
+		t.Errorf("Popped %v, want %v", got, active)
+	}
+	if f.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", f.Len())
+	}
+}
+
+func TestFanInCloseRemovesLane(t *testing.T) {
+	f := grin.NewFanIn[int]()
+	a := f.Add(4, 1)
+	b := f.Add(4, 1)
+
+	a.Push(1)
+	a.Close()
+	if f.Lanes() != 2 {
+		t.Errorf("Lanes() = %d, want 2 until the closed lane is drained", f.Lanes())
+	}
+
+	if v, ok := f.Pop(); !ok || v != 1 {
+		t.Errorf("Pop() = (%d, %v), want (1, true)", v, ok)
+	}
+	f.Pop()
+	if f.Lanes() != 1 {
+		t.Errorf("Lanes() = %d, want 1 once the closed lane is drained", f.Lanes())
+	}
+
+	// The removed lane's slot is reused
+	c := f.Add(4, 1)
+	b.Push(2)
+	c.Push(3)
+	got := make([]int, 4)
+	if n := f.PopBatch(got); n != 2 {
+		t.Errorf("PopBatch() = %d, want 2", n)
+	}
+}
+
+// notifyHook is a WaitStrategy that runs fn on the first Notify after it is set.
+type notifyHook struct {
+	fn func()
+}
+
+func (h *notifyHook) Wait(ctx context.Context, ready func() bool) error {
+	return nil
+}
+
+func (h *notifyHook) Notify() {
+	if fn := h.fn; fn != nil {
+		h.fn = nil
+		fn()
+	}
+}
+
+func TestFanInReusedIndexDuringPop(t *testing.T) {
+	hook := &notifyHook{}
+	f := grin.NewFanIn[int](grin.WithPublishEvery(2), grin.WithWaitStrategy(hook))
+	a := f.Add(4, 1)
+	b := f.Add(4, 1)
+
+	// Free index 0
+	a.Close()
+	if _, ok := f.Pop(); ok {
+		t.Fatal("Pop() = true, want false")
+	}
+
+	b.Push(1)
+	b.Flush()
+	if v, ok := f.Pop(); !ok || v != 1 {
+		t.Fatalf("Pop() = %d, %v, want 1, true", v, ok)
+	}
+
+	// b publishes its lagging head once Pop finds it empty. Add a lane in index 0
+	// then, while Pop still holds the snapshot with index 0 removed.
+	var c *grin.FanInLane[int]
+	hook.fn = func() {
+		c = f.Add(4, 1)
+		c.Push(2)
+		c.Flush()
+	}
+
+	for i := 0; i < 3; i++ {
+		if v, ok := f.Pop(); ok {
+			if v != 2 {
+				t.Fatalf("Pop() = %d, want 2", v)
+			}
+			return
+		}
+	}
+	t.Fatalf("Pop() never returned the item pushed to the lane added during Pop, c has %d items", c.Len())
+}
+
+func TestFanInReadable(t *testing.T) {
+	f := grin.NewFanIn[int]()
+	lane := f.Add(4, 1)
+	readable := f.Readable()
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		lane.Push(1)
+	}()
+
+	select {
+	case <-readable:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test timed out - Readable did not fire")
+	}
+	if v, ok := f.Pop(); !ok || v != 1 {
+		t.Errorf("Pop() = (%d, %v), want (1, true)", v, ok)
+	}
+}
+
+func TestFanInConcurrentProducers(t *testing.T) {
+	const (
+		producers = 8
+		n         = 20000
+	)
+	f := grin.NewFanIn[[2]int]()
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Join dynamically and leave when done
+			lane := f.Add(64, 1+p%3)
+			for i := 0; i < n; i++ {
+				for !lane.Push([2]int{p, i}) {
+					runtime.Gosched()
+				}
+			}
+			lane.Close()
+		}()
+	}
+
+	done := make(chan bool)
+	go func() {
+		next := make([]int, producers)
+		for total := 0; total < producers*n; {
+			v, ok := f.Pop()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if v[1] != next[v[0]] {
+				t.Errorf("Producer %d item %d, want %d", v[0], v[1], next[v[0]])
+			}
+			next[v[0]]++
+			total++
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+	wg.Wait()
+
+	// Drain to remove the closed lanes
+	f.Pop()
+	if f.Lanes() != 0 {
+		t.Errorf("Lanes() = %d, want 0 once every closed lane is drained", f.Lanes())
+	}
+}
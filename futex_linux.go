@@ -0,0 +1,175 @@
+package grin
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	futexWaitPrivate = 0 | 128 // FUTEX_WAIT | FUTEX_PRIVATE_FLAG
+	futexWakePrivate = 1 | 128 // FUTEX_WAKE | FUTEX_PRIVATE_FLAG
+)
+
+// futexCheckInterval bounds how long a parked waiter takes to notice that a context
+// without a deadline has been cancelled, as FUTEX_WAIT can't wait on a channel.
+const futexCheckInterval = 10 * time.Millisecond
+
+// futexSpins is how many times Wait polls before parking, so that a wakeup that is
+// about to happen doesn't pay for two syscalls.
+const futexSpins = 100
+
+// FutexWait parks waiters with futex(FUTEX_WAIT) and wakes them with FUTEX_WAKE,
+// avoiding the scheduler round trips of channel based parking. Notify only makes
+// the FUTEX_WAKE syscall while somebody is parked. Any number of goroutines may
+// wait on and notify the same FutexWait, so it suits a Selector over many rings.
+// To wait on a single ring, WaitReadable and WaitWritable park on a futex word in
+// the ring's own cursor lines instead.
+type FutexWait struct {
+	_       [cacheLineSize]byte // Do not remove
+	seq     atomic.Uint32       // The futex word, bumped by every Notify that has waiters
+	waiters atomic.Int32
+	_       [cacheLineSize - 8]byte // Do not remove
+}
+
+var _ WaitStrategy = (*FutexWait)(nil)
+
+// NewFutexWait creates a new futex based parking strategy.
+func NewFutexWait() *FutexWait {
+	return &FutexWait{}
+}
+
+func (f *FutexWait) Wait(ctx context.Context, ready func() bool) error {
+	for i := 0; i < futexSpins; i++ {
+		if ready() {
+			return nil
+		}
+	}
+
+	for !ready() {
+		// Register before checking ready again, so that a Notify that races with
+		// the check either bumps seq, failing FUTEX_WAIT, or happened before the check.
+		f.waiters.Add(1)
+		seq := f.seq.Load()
+		if ready() {
+			f.waiters.Add(-1)
+			return nil
+		}
+
+		futexWait(&f.seq, seq, timeoutOf(ctx))
+		f.waiters.Add(-1)
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FutexWait) Notify() {
+	if f.waiters.Load() == 0 {
+		return
+	}
+
+	f.seq.Add(1)
+	futexWake(&f.seq, math.MaxInt32)
+}
+
+// waitReadable parks the consumer on the futex word next to tail until there is an
+// item to pop.
+//
+// Only safe to call from a single consumer goroutine.
+func (b *ringBuffer[T]) waitReadable(ctx context.Context) error {
+	// The producer may be waiting for pops we have yet to publish
+	if published := b.head.Load(); b.nextHead != published {
+		b.publishHead(published, b.nextHead)
+	}
+
+	return futexPark(ctx, &b.tailFutex, &b.consumerParked, func() bool {
+		return b.tail.Load() != b.nextHead
+	})
+}
+
+// waitWritable parks the producer on the futex word next to head until there is
+// room to push.
+//
+// Only safe to call from a single producer goroutine.
+func (b *ringBuffer[T]) waitWritable(ctx context.Context) error {
+	// The consumer may be waiting for pushes we have yet to publish
+	b.Flush()
+
+	return futexPark(ctx, &b.headFutex, &b.producerParked, func() bool {
+		return b.nextTail-b.head.Load() != uint64(len(b.store))
+	})
+}
+
+// futexPark parks on word until ready returns true or ctx is done, setting parked
+// while it may sleep so that the other side knows to call futexUnpark.
+func futexPark(ctx context.Context, word, parked *atomic.Uint32, ready func() bool) error {
+	for i := 0; i < futexSpins; i++ {
+		if ready() {
+			return nil
+		}
+	}
+
+	for !ready() {
+		// Set parked before checking ready again, so that a publish that races with
+		// the check either bumps word, failing FUTEX_WAIT, or happened before the check.
+		parked.Store(1)
+		seq := word.Load()
+		if ready() {
+			parked.Store(0)
+			return nil
+		}
+
+		futexWait(word, seq, timeoutOf(ctx))
+		parked.Store(0)
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// futexUnpark wakes the goroutine parked on word by futexPark.
+func futexUnpark(word *atomic.Uint32) {
+	word.Add(1)
+	futexWake(word, 1)
+}
+
+// timeoutOf returns how long to park for before checking ctx again, or -1 to
+// park until woken.
+func timeoutOf(ctx context.Context) time.Duration {
+	if ctx.Done() == nil {
+		return -1
+	}
+
+	timeout := futexCheckInterval
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	return max(timeout, 0)
+}
+
+// futexWait parks until addr is woken, if it still holds val, or timeout elapses.
+func futexWait(addr *atomic.Uint32, val uint32, timeout time.Duration) {
+	var ts *syscall.Timespec
+	if timeout >= 0 {
+		t := syscall.NsecToTimespec(int64(timeout))
+		ts = &t
+	}
+
+	// EAGAIN, EINTR and ETIMEDOUT all mean check again
+	syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(addr)), futexWaitPrivate,
+		uintptr(val), uintptr(unsafe.Pointer(ts)), 0, 0)
+}
+
+// futexWake wakes up to n goroutines parked on addr.
+func futexWake(addr *atomic.Uint32, n int) {
+	syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(addr)), futexWakePrivate,
+		uintptr(n), 0, 0, 0)
+}
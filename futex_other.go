@@ -0,0 +1,23 @@
+//go:build !linux
+
+package grin
+
+import "sync/atomic"
+
+// FutexWait falls back to ParkWait outside of Linux.
+type FutexWait struct {
+	ParkWait
+}
+
+var _ WaitStrategy = (*FutexWait)(nil)
+
+// NewFutexWait creates a new parking strategy, backed by ParkWait outside of Linux.
+func NewFutexWait() *FutexWait {
+	f := &FutexWait{}
+	wake := make(chan struct{})
+	f.wake.Store(&wake)
+	return f
+}
+
+// futexUnpark is never called outside of Linux, as nothing parks on a futex word.
+func futexUnpark(word *atomic.Uint32) {}
@@ -4,8 +4,11 @@
 // This implementation relies on Go's atomic package which provides the necessary
 // memory barriers across all supported architectures (x86, ARM, RISC-V, etc.).
 //
-// - atomic.LoadUint64 provides acquire semantics (reads happen-before subsequent operations)
-// - atomic.StoreUint64 provides release semantics (prior writes happen-before the store)
+// - atomic.Uint64.Load provides acquire semantics (reads happen-before subsequent operations)
+// - atomic.Uint64.Store provides release semantics (prior writes happen-before the store)
+//
+// The published cursors are atomic.Uint64 rather than plain uint64 fields so that
+// they stay 64-bit aligned on 32-bit platforms such as 386 and arm.
 package grin
 
 import (
@@ -18,29 +21,106 @@ type RingBuffer[T any] interface {
 	Cap() int
 	Len() int
 	Available() int
+	Flush()
+
+	// PushSeq is Push, also returning the sequence number assigned to the item if it
+	// was pushed. Sequence numbers start at 0 and increase by one with every push.
+	PushSeq(t T) (uint64, bool)
+
+	// PopSeq is Pop, also returning the sequence number of the item. Sequences only
+	// skip when items are overwritten or expire, so a jump from the previous sequence
+	// identifies exactly which items were lost.
+	PopSeq() (T, uint64, bool)
+
+	// Stats returns a snapshot of the sequence numbers.
+	Stats() Stats
+
+	// Readable fires when the buffer goes from empty to non-empty. Only the consumer
+	// should receive from it.
+	Readable() <-chan struct{}
+
+	// Writable fires when the buffer goes from full to non-full. Only the producer
+	// should receive from it.
+	Writable() <-chan struct{}
+}
+
+// Stats is a snapshot of the sequence numbers of a ring buffer.
+type Stats struct {
+	// Head is the sequence of the next item that can be popped
+	Head uint64
+
+	// Tail is the sequence the next push is assigned, so also the number of pushes
+	Tail uint64
+
+	// Dropped is the number of items overwritten or expired before being popped
+	Dropped uint64
 }
 
-func New[T any](size int) RingBuffer[T] {
+func New[T any](size int, opts ...Option) RingBuffer[T] {
 	if size&(size-1) != 0 {
 		panic("size must be power of two")
 	}
 
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.publishEvery < 1 || o.publishEvery > size {
+		panic("publish interval must be between 1 and size")
+	}
+
+	ready := o.readiness
+	if ready == nil {
+		ready = newReadiness()
+	}
+	if o.eventFD != nil {
+		ready.eventFD = o.eventFD
+		ready.watchReadable.Store(true)
+	}
+
 	return &ringBuffer[T]{
-		store: make([]T, size),
-		mask:  uint64(size) - 1,
+		store:        make([]T, size),
+		mask:         uint64(size) - 1,
+		publishEvery: uint64(o.publishEvery),
+		clearOnPop:   o.clearOnPop,
+		wait:         o.wait,
+		ready:        ready,
 	}
 }
 
+// testHookPushFull and testHookPopEmpty let tests run the other side in the window
+// between a Push finding the buffer full, or a Pop finding it empty, and it
+// publishing its own cursor.
+var (
+	testHookPushFull func()
+	testHookPopEmpty func()
+)
+
 type ringBuffer[T any] struct {
-	store []T
-	mask  uint64
-	_     [32]byte // Do not remove
+	store        []T
+	mask         uint64
+	publishEvery uint64
+	clearOnPop   bool
+	wait         WaitStrategy
+	ready        *readiness
+	_            [cacheLineSize]byte // Do not remove
+
+	head           atomic.Uint64            // Published by the consumer, producer must use atomic operations to read
+	headFutex      atomic.Uint32            // Futex word the producer parks on, bumped by the consumer to wake it
+	producerParked atomic.Uint32            // Set by the producer while it is parked on headFutex
+	_              [cacheLineSize - 16]byte // Do not remove
+
+	tail           atomic.Uint64            // Published by the producer, consumer must use atomic operations to read
+	tailFutex      atomic.Uint32            // Futex word the consumer parks on, bumped by the producer to wake it
+	consumerParked atomic.Uint32            // Set by the consumer while it is parked on tailFutex
+	_              [cacheLineSize - 16]byte // Do not remove
 
-	head uint64   // Owned by the consumer, producer must use atomic operations to read
-	_    [56]byte // Do not remove
+	nextHead uint64                  // Owned by the consumer, at most publishEvery-1 ahead of head
+	_        [cacheLineSize - 8]byte // Do not remove
 
-	tail uint64   // Owned by the producer, consumer must use atomic operations to read
-	_    [56]byte // Do not remove
+	nextTail uint64                  // Owned by the producer, at most publishEvery-1 ahead of tail
+	_        [cacheLineSize - 8]byte // Do not remove
 }
 
 // Push adds an item to the ring buffer.
@@ -48,47 +128,191 @@ type ringBuffer[T any] struct {
 //
 // Only safe to call from a single producer goroutine.
 func (b *ringBuffer[T]) Push(t T) bool {
-	tail := b.tail
-	head := atomic.LoadUint64(&b.head)
+	tail := b.nextTail
+	head := b.head.Load()
 
 	// Dont overwrite existing data, reject new data until consumed
 	if tail-head == uint64(len(b.store)) {
-		return false
+		if testHookPushFull != nil {
+			testHookPushFull()
+		}
+
+		// Make sure the consumer can see everything it needs to drain the buffer
+		b.Flush()
+
+		// The consumer may have published pops before it could see the flushed tail,
+		// in which case it did not signal Writable, so check again
+		head = b.head.Load()
+		if tail-head == uint64(len(b.store)) {
+			return false
+		}
 	}
 
 	b.store[tail&b.mask] = t
-	atomic.StoreUint64(&b.tail, tail+1)
+	tail++
+	b.nextTail = tail
+	if published := b.tail.Load(); tail-published >= b.publishEvery {
+		b.publishTail(published, tail)
+	}
 	return true
 }
 
+// Flush publishes any pushes that have not yet been made visible to the consumer.
+// It is a no-op unless the buffer was created with WithPublishEvery.
+//
+// Only safe to call from a single producer goroutine.
+func (b *ringBuffer[T]) Flush() {
+	if published := b.tail.Load(); b.nextTail != published {
+		b.publishTail(published, b.nextTail)
+	}
+}
+
+// hasRoom reports whether the next Push will succeed. When the buffer is full it
+// flushes like Push does, so that the consumer can drain it.
+//
+// Only safe to call from a single producer goroutine.
+func (b *ringBuffer[T]) hasRoom() bool {
+	tail := b.nextTail
+	if tail-b.head.Load() != uint64(len(b.store)) {
+		return true
+	}
+
+	// As in Push, check again after flushing in case the consumer missed it
+	b.Flush()
+	return tail-b.head.Load() != uint64(len(b.store))
+}
+
+func (b *ringBuffer[T]) publishTail(prev, tail uint64) {
+	b.tail.Store(tail)
+	if b.consumerParked.Load() != 0 {
+		futexUnpark(&b.tailFutex)
+	}
+
+	// The consumer may be waiting if it had caught up with everything published
+	if b.ready.watchReadable.Load() && b.head.Load() == prev {
+		b.ready.becameReadable()
+	}
+	if b.wait != nil {
+		b.wait.Notify()
+	}
+}
+
+func (b *ringBuffer[T]) publishHead(prev, head uint64) {
+	b.head.Store(head)
+	if b.producerParked.Load() != 0 {
+		futexUnpark(&b.headFutex)
+	}
+
+	// The producer may be waiting if the buffer was full as far as it could see
+	if b.ready.watchWritable.Load() && b.tail.Load()-prev == uint64(len(b.store)) {
+		signal(b.ready.writable)
+	}
+	if b.wait != nil {
+		b.wait.Notify()
+	}
+}
+
 // Pop removes and returns an item from the ring buffer.
 // Returns (zero value, false) if the buffer is empty (non-blocking).
 //
 // Only safe to call from a single consumer goroutine.
 func (b *ringBuffer[T]) Pop() (T, bool) {
-	tail := atomic.LoadUint64(&b.tail)
-	head := b.head
+	tail := b.tail.Load()
+	head := b.nextHead
 
 	if tail == head {
+		if testHookPopEmpty != nil {
+			testHookPopEmpty()
+		}
+
+		// Publish pending pops once drained so the producer is never left waiting
+		if published := b.head.Load(); head != published {
+			b.publishHead(published, head)
+
+			// The producer may have published pushes before it could see our head, in
+			// which case it did not signal Readable, so check again
+			tail = b.tail.Load()
+		}
+		if tail == head {
+			var zero T
+			return zero, false
+		}
+	}
+
+	slot := &b.store[head&b.mask]
+	val := *slot
+	if b.clearOnPop {
+		// Release the reference so the GC can reclaim popped values
 		var zero T
-		return zero, false
+		*slot = zero
 	}
 
-	val := b.store[head&b.mask]
-	atomic.StoreUint64(&b.head, head+1)
+	head++
+	b.nextHead = head
+	if published := b.head.Load(); head-published >= b.publishEvery {
+		b.publishHead(published, head)
+	}
 	return val, true
 }
 
+// PushSeq adds an item to the ring buffer and returns its sequence number.
+// Returns false if the buffer is full (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (b *ringBuffer[T]) PushSeq(t T) (uint64, bool) {
+	seq := b.nextTail
+	return seq, b.Push(t)
+}
+
+// PopSeq removes and returns an item and its sequence number from the ring buffer.
+// Returns (zero value, 0, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (b *ringBuffer[T]) PopSeq() (T, uint64, bool) {
+	seq := b.nextHead
+	t, ok := b.Pop()
+	if !ok {
+		return t, 0, false
+	}
+	return t, seq, true
+}
+
+// Stats returns the published head and tail. It is safe to call from any goroutine.
+func (b *ringBuffer[T]) Stats() Stats {
+	return Stats{
+		Head: b.head.Load(),
+		Tail: b.tail.Load(),
+	}
+}
+
 func (b *ringBuffer[T]) Cap() int {
 	return len(b.store)
 }
 
 func (b *ringBuffer[T]) Len() int {
-	tail := atomic.LoadUint64(&b.tail)
-	head := atomic.LoadUint64(&b.head)
+	tail := b.tail.Load()
+	head := b.head.Load()
 	return int(tail - head)
 }
 
 func (b *ringBuffer[T]) Available() int {
 	return b.Cap() - b.Len()
 }
+
+// Readable returns a channel that fires when the buffer goes from empty to
+// non-empty. It may fire spuriously, so always Pop to check. Get the channel before
+// the Pop that finds the buffer empty, otherwise a transition can be missed.
+//
+// Only safe to receive from a single consumer goroutine.
+func (b *ringBuffer[T]) Readable() <-chan struct{} {
+	return b.ready.Readable()
+}
+
+// Writable returns a channel that fires when the buffer goes from full to
+// non-full. It may fire spuriously, so always Push to check. Get the channel before
+// the Push that finds the buffer full, otherwise a transition can be missed.
+//
+// Only safe to receive from a single producer goroutine.
+func (b *ringBuffer[T]) Writable() <-chan struct{} {
+	return b.ready.Writable()
+}
@@ -2,6 +2,8 @@ package grin_test
 
 import (
 	"container/ring"
+	"context"
+	"runtime"
 	"testing"
 
 	"github.com/andrewwormald/grin"
@@ -78,6 +80,19 @@ func BenchmarkStdRing_Sequential(b *testing.B) {
 	}
 }
 
+func BenchmarkLocal_Sequential(b *testing.B) {
+	buf := grin.NewLocal[int](256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 128; j++ {
+			buf.Push(j)
+		}
+		for j := 0; j < 128; j++ {
+			buf.Pop()
+		}
+	}
+}
+
 func BenchmarkGrin_Wraparound(b *testing.B) {
 	buf := grin.New[int](64)
 	for i := 0; i < 32; i++ {
@@ -126,6 +141,35 @@ func BenchmarkGrin_FillDrain(b *testing.B) {
 	}
 }
 
+func BenchmarkGrin_FillDrainPublishEvery(b *testing.B) {
+	buf := grin.New[int](512, grin.WithPublishEvery(64))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 512; j++ {
+			for !buf.Push(j) {
+				buf.Pop()
+			}
+		}
+		buf.Flush()
+		for j := 0; j < 512; j++ {
+			buf.Pop()
+		}
+	}
+}
+
+func BenchmarkLocal_FillDrain(b *testing.B) {
+	buf := grin.NewLocal[int](512)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 512; j++ {
+			buf.Push(j)
+		}
+		for j := 0; j < 512; j++ {
+			buf.Pop()
+		}
+	}
+}
+
 func BenchmarkStdRing_FillDrain(b *testing.B) {
 	r := ring.New(512)
 	b.ResetTimer()
@@ -261,3 +305,109 @@ func BenchmarkChannel_LargeBuffer(b *testing.B) {
 		}
 	}
 }
+
+// benchmarkWake measures the round trip of waking a parked consumer, which pops
+// and wakes the parked producer in turn. With lockThreads both sides run on their
+// own OS thread, as latency sensitive code usually does.
+func benchmarkWake(b *testing.B, w grin.WaitStrategy, lockThreads bool) {
+	ping := grin.New[int](1, grin.WithWaitStrategy(w))
+	pong := grin.New[int](1, grin.WithWaitStrategy(w))
+	ctx := context.Background()
+
+	if lockThreads {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	go func() {
+		if lockThreads {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+		}
+		for i := 0; i < b.N; i++ {
+			_ = w.Wait(ctx, func() bool { return ping.Len() > 0 })
+			v, _ := ping.Pop()
+			pong.Push(v)
+		}
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ping.Push(i)
+		_ = w.Wait(ctx, func() bool { return pong.Len() > 0 })
+		pong.Pop()
+	}
+}
+
+func BenchmarkWake_Park(b *testing.B) {
+	benchmarkWake(b, grin.NewParkWait(), false)
+}
+
+func BenchmarkWake_Futex(b *testing.B) {
+	benchmarkWake(b, grin.NewFutexWait(), false)
+}
+
+func BenchmarkWake_ParkLocked(b *testing.B) {
+	benchmarkWake(b, grin.NewParkWait(), true)
+}
+
+func BenchmarkWake_FutexLocked(b *testing.B) {
+	benchmarkWake(b, grin.NewFutexWait(), true)
+}
+
+// benchmarkWakeRing is benchmarkWake parking on the futex words in each ring's own
+// cursor lines through WaitReadable, rather than on a shared strategy.
+func benchmarkWakeRing(b *testing.B, lockThreads bool) {
+	ping := grin.New[int](1)
+	pong := grin.New[int](1)
+	ctx := context.Background()
+
+	if lockThreads {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	go func() {
+		if lockThreads {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+		}
+		for i := 0; i < b.N; i++ {
+			_ = grin.WaitReadable(ctx, ping)
+			v, _ := ping.Pop()
+			pong.Push(v)
+		}
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ping.Push(i)
+		_ = grin.WaitReadable(ctx, pong)
+		pong.Pop()
+	}
+}
+
+func BenchmarkWake_FutexRing(b *testing.B) {
+	benchmarkWakeRing(b, false)
+}
+
+func BenchmarkWake_FutexRingLocked(b *testing.B) {
+	benchmarkWakeRing(b, true)
+}
+
+func BenchmarkWake_Channel(b *testing.B) {
+	ping := make(chan int)
+	pong := make(chan int)
+
+	go func() {
+		for i := 0; i < b.N; i++ {
+			pong <- <-ping
+		}
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ping <- i
+		<-pong
+	}
+}
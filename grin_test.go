@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+	"weak"
 
 	"github.com/andrewwormald/grin"
 )
@@ -425,3 +426,162 @@ func TestConcurrentBackpressure(t *testing.T) {
 		}
 	}
 }
+
+func TestPublishEvery(t *testing.T) {
+	buf := grin.New[int](8, grin.WithPublishEvery(4))
+
+	for i := 0; i < 3; i++ {
+		if !buf.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+
+	if got, ok := buf.Pop(); ok {
+		t.Fatalf("Pop() before publication = (%d, %v), want (0, false)", got, ok)
+	}
+
+	buf.Push(3)
+
+	for i := 0; i < 4; i++ {
+		if got, ok := buf.Pop(); !ok || got != i {
+			t.Errorf("Pop() = (%d, %v), want (%d, true)", got, ok, i)
+		}
+	}
+}
+
+func TestPublishEveryFlush(t *testing.T) {
+	buf := grin.New[int](8, grin.WithPublishEvery(8))
+
+	buf.Push(1)
+	if _, ok := buf.Pop(); ok {
+		t.Fatal("Pop() succeeded before Flush()")
+	}
+
+	buf.Flush()
+	if got, ok := buf.Pop(); !ok || got != 1 {
+		t.Errorf("Pop() after Flush() = (%d, %v), want (1, true)", got, ok)
+	}
+}
+
+func TestPublishEveryFull(t *testing.T) {
+	buf := grin.New[int](4, grin.WithPublishEvery(4))
+
+	for i := 0; i < 4; i++ {
+		buf.Push(i)
+	}
+	if buf.Push(4) {
+		t.Fatal("Push(4) succeeded when buffer should be full")
+	}
+
+	// The consumer publishes head lazily, so the producer only sees free slots
+	// once a full batch has been popped or the buffer is drained.
+	buf.Pop()
+	if buf.Push(4) {
+		t.Error("Push(4) succeeded before consumer published head")
+	}
+
+	for i := 1; i < 4; i++ {
+		if got, ok := buf.Pop(); !ok || got != i {
+			t.Errorf("Pop() = (%d, %v), want (%d, true)", got, ok, i)
+		}
+	}
+	if !buf.Push(4) {
+		t.Error("Push(4) failed after draining the buffer")
+	}
+}
+
+func TestPublishEveryInvalid(t *testing.T) {
+	for _, n := range []int{0, 16} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("WithPublishEvery(%d) should panic for size 8", n)
+				}
+			}()
+			grin.New[int](8, grin.WithPublishEvery(n))
+		}()
+	}
+}
+
+func TestConcurrentPublishEvery(t *testing.T) {
+	buf := grin.New[int](1024, grin.WithPublishEvery(32))
+	const numItems = 100000
+	done := make(chan bool, 2)
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for !buf.Push(i) {
+				runtime.Gosched()
+			}
+		}
+		buf.Flush()
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for {
+				if val, ok := buf.Pop(); ok {
+					if val != i {
+						t.Errorf("got %d, want %d", val, i)
+					}
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+		done <- true
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatal("Test timed out - possible deadlock")
+		}
+	}
+}
+
+type bigStruct struct {
+	payload [1 << 16]byte
+}
+
+func TestPopReleasesReference(t *testing.T) {
+	buf := grin.New[*bigStruct](8)
+
+	wp := pushWeak(buf)
+	if _, ok := buf.Pop(); !ok {
+		t.Fatal("Pop() failed")
+	}
+
+	runtime.GC()
+	if wp.Value() != nil {
+		t.Error("popped value is still reachable through the buffer")
+	}
+}
+
+func TestPopWithoutClearRetainsReference(t *testing.T) {
+	buf := grin.New[*bigStruct](8, grin.WithClearOnPop(false))
+
+	wp := pushWeak(buf)
+	if _, ok := buf.Pop(); !ok {
+		t.Fatal("Pop() failed")
+	}
+
+	runtime.GC()
+	if wp.Value() == nil {
+		t.Error("popped value was collected even though clearing is disabled")
+	}
+	runtime.KeepAlive(buf)
+}
+
+// pushWeak pushes a new value and returns a weak pointer to it, keeping no strong
+// reference on the caller's stack.
+//
+//go:noinline
+func pushWeak(buf grin.RingBuffer[*bigStruct]) weak.Pointer[bigStruct] {
+	v := new(bigStruct)
+	buf.Push(v)
+	return weak.Make(v)
+}
@@ -0,0 +1,387 @@
+// Package grintest runs code built on grin under a deterministic scheduler, to
+// find the interleavings that break it.
+//
+// A Scheduler runs tasks one at a time as cooperative goroutines. Rings created
+// with NewRing switch to another task at every Push, Pop and atomic operation, and
+// the Scheduler picks which task runs next from a seeded random source or by
+// exploring every possible order. A failing run reports the seed or schedule that
+// replays it exactly.
+//
+//	grintest.Random(t, 1000, func(s *grintest.Scheduler) {
+//	    buf := grintest.NewRing[int](s, 4)
+//	    s.Go("producer", func() error { ... })
+//	    s.Go("consumer", func() error { ... })
+//	})
+//
+// Tasks must only block through the Scheduler, using it as a grin.WaitStrategy,
+// and must not block on channels such as those returned by Readable.
+package grintest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/andrewwormald/grin"
+)
+
+// Option configures a run of the Scheduler.
+type Option func(*options)
+
+type options struct {
+	maxSteps int
+}
+
+func defaultOptions() options {
+	return options{
+		maxSteps: 100000,
+	}
+}
+
+// WithMaxSteps fails a run that takes more than n steps, which usually means a
+// task is spinning on a condition that can never become true. The default is
+// 100000.
+func WithMaxSteps(n int) Option {
+	return func(o *options) {
+		o.maxSteps = n
+	}
+}
+
+var (
+	// ErrDeadlock is returned when every unfinished task is waiting.
+	ErrDeadlock = errors.New("grintest: deadlock, every task is waiting")
+
+	// ErrMaxSteps is returned when a run takes more steps than WithMaxSteps allows.
+	ErrMaxSteps = errors.New("grintest: too many steps, is a task spinning forever?")
+
+	// errAborted unwinds the tasks of a run that has failed
+	errAborted = errors.New("grintest: run aborted")
+)
+
+// Failure describes a failed run and how to replay it.
+type Failure struct {
+	Seed     uint64   // Seed of a random run
+	Schedule []int    // Choices of an exhaustive run, see ReplaySchedule
+	Trace    []string // Name of the task that ran at each step
+	Err      error
+}
+
+func (f *Failure) Error() string {
+	trace := f.Trace
+	if len(trace) > 32 {
+		trace = append([]string{"..."}, trace[len(trace)-32:]...)
+	}
+
+	if f.Schedule != nil {
+		return fmt.Sprintf("schedule %v: %v\ntrace: %s", f.Schedule, f.Err, strings.Join(trace, " "))
+	}
+	return fmt.Sprintf("seed %d: %v\ntrace: %s", f.Seed, f.Err, strings.Join(trace, " "))
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+type task struct {
+	name  string
+	fn    func() error
+	wake  chan struct{}
+	ready func() bool // Set while waiting, the task can only run once it returns true
+	done  bool
+}
+
+// Scheduler runs tasks one at a time, switching between them only at yield points.
+// It is created by Run, Random, Exhaustive and the replay functions.
+type Scheduler struct {
+	opts    options
+	choose  func(n int) int
+	tasks   []*task
+	finally []func() error
+
+	current *task
+	yield   chan struct{}
+	abort   chan struct{}
+	errs    []error
+	trace   []string
+}
+
+var _ grin.WaitStrategy = (*Scheduler)(nil)
+
+// Go adds a task to the run. It is only safe to call from the setup function.
+// A task fails the run by returning an error or panicking.
+func (s *Scheduler) Go(name string, fn func() error) {
+	s.tasks = append(s.tasks, &task{
+		name: name,
+		fn:   fn,
+		wake: make(chan struct{}),
+	})
+}
+
+// Finally adds a check that runs once every task has finished. It is only safe to
+// call from the setup function.
+func (s *Scheduler) Finally(fn func() error) {
+	s.finally = append(s.finally, fn)
+}
+
+// Yield lets the scheduler switch to another task. Rings created with NewRing call
+// it at every operation, and tasks can call it between their own steps. It is a
+// no-op outside of a task.
+func (s *Scheduler) Yield() {
+	t := s.current
+	if t == nil {
+		return
+	}
+
+	select {
+	case s.yield <- struct{}{}:
+	case <-s.abort:
+		panic(errAborted)
+	}
+
+	select {
+	case <-t.wake:
+	case <-s.abort:
+		panic(errAborted)
+	}
+}
+
+// Wait yields until ready returns true, so the task only runs again once it can
+// make progress. A run where every task is waiting fails with ErrDeadlock.
+func (s *Scheduler) Wait(ctx context.Context, ready func() bool) error {
+	t := s.current
+	if t == nil {
+		return errors.New("grintest: Wait called outside of a task")
+	}
+
+	for !ready() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t.ready = ready
+		s.Yield()
+		t.ready = nil
+	}
+	return nil
+}
+
+// Notify is a no-op, waiting tasks are checked at every step.
+func (s *Scheduler) Notify() {}
+
+// run runs every task to completion, switching between them as choose decides.
+func (s *Scheduler) run() error {
+	s.yield = make(chan struct{})
+	s.abort = make(chan struct{})
+	defer close(s.abort)
+
+	for _, t := range s.tasks {
+		go s.start(t)
+	}
+
+	var runnable []*task
+	for step := 0; ; step++ {
+		if len(s.errs) > 0 {
+			return errors.Join(s.errs...)
+		}
+		if step == s.opts.maxSteps {
+			return ErrMaxSteps
+		}
+
+		runnable = runnable[:0]
+		waiting := false
+		for _, t := range s.tasks {
+			switch {
+			case t.done:
+			case t.ready != nil && !t.ready():
+				waiting = true
+			default:
+				runnable = append(runnable, t)
+			}
+		}
+
+		if len(runnable) == 0 {
+			if waiting {
+				return ErrDeadlock
+			}
+			break
+		}
+
+		t := runnable[0]
+		if len(runnable) > 1 {
+			t = runnable[s.choose(len(runnable))]
+		}
+		s.trace = append(s.trace, t.name)
+
+		s.current = t
+		t.wake <- struct{}{}
+		<-s.yield
+		s.current = nil
+	}
+
+	for _, fn := range s.finally {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start runs a task once it is first scheduled.
+func (s *Scheduler) start(t *task) {
+	select {
+	case <-t.wake:
+	case <-s.abort:
+		return
+	}
+
+	err := s.call(t)
+	if errors.Is(err, errAborted) {
+		return
+	}
+
+	t.done = true
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("task %q: %w", t.name, err))
+	}
+
+	select {
+	case s.yield <- struct{}{}:
+	case <-s.abort:
+	}
+}
+
+func (s *Scheduler) call(t *task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == errAborted {
+				err = errAborted
+				return
+			}
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return t.fn()
+}
+
+// Run runs the tasks added by setup under the schedule generated from seed, and
+// returns a *Failure if the run fails.
+func Run(seed uint64, setup func(s *Scheduler), opts ...Option) error {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	s := newScheduler(opts, rng.IntN)
+	setup(s)
+
+	if err := s.run(); err != nil {
+		return &Failure{Seed: seed, Trace: s.trace, Err: err}
+	}
+	return nil
+}
+
+// Random runs setup under seeds random schedules, seeded 0 to seeds-1, and fails
+// t with the first failing seed, which Replay reruns exactly.
+func Random(t testing.TB, seeds int, setup func(s *Scheduler), opts ...Option) {
+	t.Helper()
+
+	for seed := uint64(0); seed < uint64(seeds); seed++ {
+		if err := Run(seed, setup, opts...); err != nil {
+			t.Fatalf("%v\nreplay with grintest.Replay(t, %d, setup)", err, seed)
+		}
+	}
+}
+
+// Replay reruns the schedule of a seed reported by Random, failing t if it fails.
+func Replay(t testing.TB, seed uint64, setup func(s *Scheduler), opts ...Option) {
+	t.Helper()
+
+	if err := Run(seed, setup, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Exhaustive runs setup under every possible schedule, up to maxSchedules of
+// them, and fails t with the first failing schedule, which ReplaySchedule reruns
+// exactly. It returns the number of schedules run.
+func Exhaustive(t testing.TB, maxSchedules int, setup func(s *Scheduler), opts ...Option) int {
+	t.Helper()
+
+	var prefix []int
+	for n := 1; ; n++ {
+		choices, err := runSchedule(prefix, setup, opts)
+		if err != nil {
+			t.Fatalf("%v\nreplay with grintest.ReplaySchedule(t, %#v, setup)", err, err.(*Failure).Schedule)
+		}
+
+		prefix = nextSchedule(choices)
+		if prefix == nil || n == maxSchedules {
+			return n
+		}
+	}
+}
+
+// ReplaySchedule reruns a schedule reported by Exhaustive, failing t if it fails.
+func ReplaySchedule(t testing.TB, schedule []int, setup func(s *Scheduler), opts ...Option) {
+	t.Helper()
+
+	if _, err := runSchedule(schedule, setup, opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// choice is a point in a schedule where one of n tasks was picked.
+type choice struct {
+	picked, n int
+}
+
+// runSchedule follows prefix, then picks the first runnable task at every choice
+// point after it, and returns the choices made.
+func runSchedule(prefix []int, setup func(s *Scheduler), opts []Option) ([]choice, error) {
+	var choices []choice
+	s := newScheduler(opts, func(n int) int {
+		picked := 0
+		if i := len(choices); i < len(prefix) {
+			picked = min(prefix[i], n-1)
+		}
+		choices = append(choices, choice{picked, n})
+		return picked
+	})
+	setup(s)
+
+	if err := s.run(); err != nil {
+		schedule := make([]int, len(choices))
+		for i, c := range choices {
+			schedule[i] = c.picked
+		}
+		return choices, &Failure{Schedule: schedule, Trace: s.trace, Err: err}
+	}
+	return choices, nil
+}
+
+// nextSchedule returns the prefix of the schedule after choices in depth first
+// order, or nil once every schedule has been run.
+func nextSchedule(choices []choice) []int {
+	for i := len(choices) - 1; i >= 0; i-- {
+		if c := choices[i]; c.picked+1 < c.n {
+			prefix := make([]int, i+1)
+			for j := range choices[:i] {
+				prefix[j] = choices[j].picked
+			}
+			prefix[i] = c.picked + 1
+			return prefix
+		}
+	}
+	return nil
+}
+
+func newScheduler(opts []Option, choose func(n int) int) *Scheduler {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Scheduler{
+		opts:   o,
+		choose: choose,
+	}
+}
@@ -0,0 +1,241 @@
+package grintest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/andrewwormald/grin/grintest"
+)
+
+// transfer pushes n items through a ring and sets done once it has pushed them
+// all. The consumer stops once it finds the ring empty with done set beforehand.
+// With buggyShutdown it reads done after the empty Pop instead of before, so it
+// misses the last item if the producer pushes it and finishes in between.
+func transfer(n int, buggyShutdown bool, opts ...grintest.RingOption) func(s *grintest.Scheduler) {
+	return func(s *grintest.Scheduler) {
+		ctx := context.Background()
+		buf := grintest.NewRing[int](s, 2, opts...)
+		done := false
+		var got []int
+
+		s.Go("producer", func() error {
+			for i := 0; i < n; i++ {
+				for !buf.Push(i) {
+					if err := s.Wait(ctx, func() bool { return buf.Available() > 0 }); err != nil {
+						return err
+					}
+				}
+			}
+			buf.Flush()
+			s.Yield()
+			done = true
+			return nil
+		})
+
+		s.Go("consumer", func() error {
+			for {
+				finished := done
+				s.Yield()
+
+				v, ok := buf.Pop()
+				if ok {
+					got = append(got, v)
+					continue
+				}
+
+				if buggyShutdown {
+					s.Yield()
+					finished = done
+				}
+				if finished {
+					return nil
+				}
+
+				if err := s.Wait(ctx, func() bool { return done || buf.Len() > 0 }); err != nil {
+					return err
+				}
+			}
+		})
+
+		s.Finally(func() error {
+			if len(got) != n {
+				return fmt.Errorf("consumer got %v, want %d items", got, n)
+			}
+			return nil
+		})
+	}
+}
+
+func TestExhaustiveCorrect(t *testing.T) {
+	runs := grintest.Exhaustive(t, 20000, transfer(2, false))
+	if runs < 10 {
+		t.Errorf("Exhaustive() ran %d schedules, want many", runs)
+	}
+}
+
+func TestExhaustivePublishEvery(t *testing.T) {
+	grintest.Exhaustive(t, 20000, transfer(3, false, grintest.WithPublishEvery(2)))
+}
+
+func TestRandomCorrect(t *testing.T) {
+	grintest.Random(t, 200, transfer(5, false))
+	grintest.Random(t, 200, transfer(5, false, grintest.WithPublishEvery(2)))
+}
+
+// A producer that never flushes leaves its last pushes unpublished, so the
+// consumer waits for them forever.
+func TestPublishEveryWithoutFlush(t *testing.T) {
+	setup := func(flush bool) func(s *grintest.Scheduler) {
+		return func(s *grintest.Scheduler) {
+			buf := grintest.NewRing[int](s, 4, grintest.WithPublishEvery(2))
+			s.Go("producer", func() error {
+				buf.Push(1)
+				if flush {
+					buf.Flush()
+				}
+				return nil
+			})
+			s.Go("consumer", func() error {
+				return s.Wait(context.Background(), func() bool { return buf.Len() > 0 })
+			})
+		}
+	}
+
+	if err := grintest.Run(0, setup(false)); !errors.Is(err, grintest.ErrDeadlock) {
+		t.Errorf("Run() without Flush error = %v, want %v", err, grintest.ErrDeadlock)
+	}
+	if err := grintest.Run(0, setup(true)); err != nil {
+		t.Errorf("Run() with Flush error = %v, want nil", err)
+	}
+}
+
+func TestRunFindsAndReplaysBug(t *testing.T) {
+	var failure *grintest.Failure
+	var seed uint64
+	for ; seed < 1000; seed++ {
+		if err := grintest.Run(seed, transfer(3, true)); err != nil {
+			if !errors.As(err, &failure) {
+				t.Fatalf("Run() error = %T, want *Failure", err)
+			}
+			break
+		}
+	}
+	if failure == nil {
+		t.Fatal("No seed found the shutdown race")
+	}
+	if failure.Seed != seed {
+		t.Errorf("Failure.Seed = %d, want %d", failure.Seed, seed)
+	}
+
+	// The same seed replays exactly the same interleaving
+	err := grintest.Run(seed, transfer(3, true))
+	var replayed *grintest.Failure
+	if !errors.As(err, &replayed) || !slices.Equal(replayed.Trace, failure.Trace) {
+		t.Errorf("Replaying seed %d gave %v, want the same failure", seed, err)
+	}
+}
+
+// fatalRecorder captures the first Fatal call instead of failing the test.
+type fatalRecorder struct {
+	testing.TB
+	args []any
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...any) {
+	r.args = args
+	runtime.Goexit()
+}
+
+func (r *fatalRecorder) Fatal(args ...any) {
+	r.args = args
+	runtime.Goexit()
+}
+
+func (r *fatalRecorder) Helper() {}
+
+// recordFailure runs fn with a fatalRecorder and returns the Failure it failed
+// with, if any.
+func recordFailure(t *testing.T, fn func(tb testing.TB)) *grintest.Failure {
+	r := &fatalRecorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+	<-done
+
+	var failure *grintest.Failure
+	if len(r.args) > 0 {
+		if err, ok := r.args[0].(error); ok && errors.As(err, &failure) {
+			return failure
+		}
+		t.Fatalf("Failed with %v, want a *Failure", r.args)
+	}
+	return nil
+}
+
+func TestExhaustiveFindsAndReplaysBug(t *testing.T) {
+	failure := recordFailure(t, func(tb testing.TB) {
+		grintest.Exhaustive(tb, 100000, transfer(2, true))
+	})
+	if failure == nil {
+		t.Fatal("Exhaustive() did not find the shutdown race")
+	}
+
+	replayed := recordFailure(t, func(tb testing.TB) {
+		grintest.ReplaySchedule(tb, failure.Schedule, transfer(2, true))
+	})
+	if replayed == nil || !slices.Equal(replayed.Trace, failure.Trace) {
+		t.Errorf("ReplaySchedule(%v) gave %v, want the same failure", failure.Schedule, replayed)
+	}
+
+	// The fixed version passes the schedule that broke the buggy one
+	grintest.ReplaySchedule(t, failure.Schedule, transfer(2, false))
+}
+
+func TestDeadlock(t *testing.T) {
+	err := grintest.Run(0, func(s *grintest.Scheduler) {
+		buf := grintest.NewRing[int](s, 2)
+		s.Go("consumer", func() error {
+			return s.Wait(context.Background(), func() bool { return buf.Len() > 0 })
+		})
+	})
+	if !errors.Is(err, grintest.ErrDeadlock) {
+		t.Errorf("Run() error = %v, want %v", err, grintest.ErrDeadlock)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	err := grintest.Run(0, func(s *grintest.Scheduler) {
+		buf := grintest.NewRing[int](s, 2)
+		s.Go("spinner", func() error {
+			for buf.Len() == 0 {
+			}
+			return nil
+		})
+	}, grintest.WithMaxSteps(100))
+	if !errors.Is(err, grintest.ErrMaxSteps) {
+		t.Errorf("Run() error = %v, want %v", err, grintest.ErrMaxSteps)
+	}
+}
+
+func TestTaskPanic(t *testing.T) {
+	err := grintest.Run(0, func(s *grintest.Scheduler) {
+		s.Go("other", func() error {
+			for {
+				s.Yield()
+			}
+		})
+		s.Go("panics", func() error {
+			s.Yield()
+			panic("boom")
+		})
+	})
+	if err == nil {
+		t.Fatal("Run() error = nil, want the panic")
+	}
+}
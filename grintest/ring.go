@@ -0,0 +1,196 @@
+package grintest
+
+import "github.com/andrewwormald/grin"
+
+// RingOption configures a ring created with NewRing.
+type RingOption func(*ringOptions)
+
+type ringOptions struct {
+	publishEvery int
+}
+
+// WithPublishEvery models grin.WithPublishEvery: the ring only publishes its
+// cursors every n pushes or pops, so a producer that never calls Flush can leave
+// items the consumer can't see.
+func WithPublishEvery(n int) RingOption {
+	return func(o *ringOptions) {
+		o.publishEvery = n
+	}
+}
+
+// ring is a grin.RingBuffer that follows the algorithm of grin.New, including
+// batched publication, flushing when full and publishing when empty, yielding to
+// the Scheduler before every atomic load and store. As only one task runs at a
+// time it needs no atomics itself.
+type ring[T any] struct {
+	s            *Scheduler
+	store        []T
+	mask         uint64
+	publishEvery uint64
+
+	head     uint64 // Published by the consumer
+	tail     uint64 // Published by the producer
+	nextHead uint64 // Owned by the consumer, at most publishEvery-1 ahead of head
+	nextTail uint64 // Owned by the producer, at most publishEvery-1 ahead of tail
+
+	readable chan struct{}
+	writable chan struct{}
+}
+
+var _ grin.RingBuffer[int] = (*ring[int])(nil)
+
+// NewRing creates a ring buffer with the specified size whose every operation is
+// a point at which s may switch tasks. Size must be a power of 2, and the publish
+// interval between 1 and size, otherwise it panics.
+func NewRing[T any](s *Scheduler, size int, opts ...RingOption) grin.RingBuffer[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+
+	o := ringOptions{publishEvery: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.publishEvery < 1 || o.publishEvery > size {
+		panic("publish interval must be between 1 and size")
+	}
+
+	return &ring[T]{
+		s:            s,
+		store:        make([]T, size),
+		mask:         uint64(size) - 1,
+		publishEvery: uint64(o.publishEvery),
+		readable:     make(chan struct{}, 1),
+		writable:     make(chan struct{}, 1),
+	}
+}
+
+// load and store stand in for the atomic operations of grin.New
+func (r *ring[T]) load(v *uint64) uint64 {
+	r.s.Yield()
+	return *v
+}
+
+func (r *ring[T]) storeCursor(v *uint64, x uint64) {
+	r.s.Yield()
+	*v = x
+}
+
+func (r *ring[T]) Push(t T) bool {
+	_, ok := r.PushSeq(t)
+	return ok
+}
+
+func (r *ring[T]) PushSeq(t T) (uint64, bool) {
+	tail := r.nextTail
+	head := r.load(&r.head)
+	if tail-head == uint64(len(r.store)) {
+		// As grin.New does, flush and check again before reporting the ring full
+		r.Flush()
+		head = r.load(&r.head)
+		if tail-head == uint64(len(r.store)) {
+			return tail, false
+		}
+	}
+
+	r.store[tail&r.mask] = t
+	r.nextTail = tail + 1
+	if published := r.load(&r.tail); tail+1-published >= r.publishEvery {
+		r.publishTail(published, tail+1)
+	}
+	return tail, true
+}
+
+// Flush publishes any pushes that have not yet been made visible to the consumer.
+func (r *ring[T]) Flush() {
+	if published := r.load(&r.tail); r.nextTail != published {
+		r.publishTail(published, r.nextTail)
+	}
+}
+
+func (r *ring[T]) publishTail(prev, tail uint64) {
+	r.storeCursor(&r.tail, tail)
+	if r.load(&r.head) == prev {
+		signal(r.readable)
+	}
+}
+
+func (r *ring[T]) publishHead(prev, head uint64) {
+	r.storeCursor(&r.head, head)
+	if r.load(&r.tail)-prev == uint64(len(r.store)) {
+		signal(r.writable)
+	}
+}
+
+func (r *ring[T]) Pop() (T, bool) {
+	t, _, ok := r.PopSeq()
+	return t, ok
+}
+
+func (r *ring[T]) PopSeq() (T, uint64, bool) {
+	head := r.nextHead
+	tail := r.load(&r.tail)
+	if tail == head {
+		// As grin.New does, publish pending pops and check again before reporting
+		// the ring empty
+		if published := r.load(&r.head); head != published {
+			r.publishHead(published, head)
+			tail = r.load(&r.tail)
+		}
+		if tail == head {
+			var zero T
+			return zero, 0, false
+		}
+	}
+
+	slot := &r.store[head&r.mask]
+	val := *slot
+	var zero T
+	*slot = zero
+
+	r.nextHead = head + 1
+	if published := r.load(&r.head); head+1-published >= r.publishEvery {
+		r.publishHead(published, head+1)
+	}
+	return val, head, true
+}
+
+func (r *ring[T]) Cap() int {
+	return len(r.store)
+}
+
+func (r *ring[T]) Len() int {
+	tail := r.load(&r.tail)
+	head := r.load(&r.head)
+	return int(tail - head)
+}
+
+func (r *ring[T]) Available() int {
+	return r.Cap() - r.Len()
+}
+
+func (r *ring[T]) Stats() grin.Stats {
+	tail := r.load(&r.tail)
+	head := r.load(&r.head)
+	return grin.Stats{Head: head, Tail: tail}
+}
+
+// Readable returns a channel that is signalled when the ring goes from empty to
+// non-empty. Tasks must not block on it, wait through the Scheduler instead.
+func (r *ring[T]) Readable() <-chan struct{} {
+	return r.readable
+}
+
+// Writable returns a channel that is signalled when the ring goes from full to
+// non-full. Tasks must not block on it, wait through the Scheduler instead.
+func (r *ring[T]) Writable() <-chan struct{} {
+	return r.writable
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
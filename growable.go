@@ -0,0 +1,215 @@
+package grin
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Growable is a SPSC ring buffer that grows when full instead of rejecting pushes.
+//
+// When the current ring is full the producer allocates one twice the size and links
+// it behind the old one, without locks. The consumer drains the old ring before
+// switching to the new one so no items are lost or reordered. Growth stops at the
+// configured maximum capacity, and Push returns false like RingBuffer once a ring
+// of that size is full. Older rings that have yet to be drained are not counted
+// towards the maximum, so at most 2*maxSize items are ever buffered.
+type Growable[T any] struct {
+	minSize     int
+	maxSize     int
+	shrinkAfter time.Duration
+	opts        []Option
+	ready       *readiness
+	_           [cacheLineSize]byte // Do not remove
+
+	read   atomic.Pointer[segment[T]] // Owned by the consumer
+	popped atomic.Uint64              // Owned by the consumer, segments restart their sequences
+	_      [cacheLineSize]byte        // Do not remove
+
+	write     atomic.Pointer[segment[T]] // Owned by the producer
+	pushed    atomic.Uint64              // Owned by the producer
+	resizedAt time.Time                  // Owned by the producer
+	_         [cacheLineSize]byte        // Do not remove
+}
+
+var _ RingBuffer[int] = (*Growable[int])(nil)
+
+// NewGrowable creates a new growable ring buffer that starts with size slots and
+// doubles up to maxSize. Both must be powers of 2 with size <= maxSize, otherwise
+// it panics. Use WithShrinkAfter to release memory after a burst has passed.
+func NewGrowable[T any](size, maxSize int, opts ...Option) *Growable[T] {
+	if maxSize&(maxSize-1) != 0 {
+		panic("max size must be power of two")
+	}
+	if size > maxSize {
+		panic("size must not exceed max size")
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Every segment signals the same readiness channels
+	ready := newReadiness()
+	opts = append(opts[:len(opts):len(opts)], func(o *options) {
+		o.readiness = ready
+	})
+
+	g := &Growable[T]{
+		minSize:     size,
+		maxSize:     maxSize,
+		shrinkAfter: o.shrinkAfter,
+		opts:        opts,
+		ready:       ready,
+	}
+
+	s := newSegment[T](size, opts)
+	g.read.Store(s)
+	g.write.Store(s)
+	return g
+}
+
+// Push adds an item to the ring buffer, growing it if it is full.
+// Returns false if the buffer is full at its maximum capacity (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (g *Growable[T]) Push(t T) bool {
+	_, ok := g.PushSeq(t)
+	return ok
+}
+
+// PushSeq adds an item to the ring buffer, growing it if it is full, and returns
+// its sequence number.
+// Returns false if the buffer is full at its maximum capacity (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (g *Growable[T]) PushSeq(t T) (uint64, bool) {
+	seq := g.pushed.Load()
+	w := g.writable()
+	if w == nil {
+		return seq, false
+	}
+
+	// Count the push before the consumer can pop it, so Stats never shows more
+	// pops than pushes
+	g.pushed.Store(seq + 1)
+	w.ring.Push(t)
+	return seq, true
+}
+
+// writable returns the segment the next push goes to, growing or shrinking the
+// buffer as needed. Returns nil if the buffer is full at its maximum capacity.
+func (g *Growable[T]) writable() *segment[T] {
+	w := g.write.Load()
+
+	if g.shrinkAfter > 0 && w.ring.Cap() > g.minSize && w.ring.Len() == 0 &&
+		time.Since(g.resizedAt) >= g.shrinkAfter && g.Len() == 0 {
+		w = g.resize(w, w.ring.Cap()/2)
+	}
+
+	if w.ring.hasRoom() {
+		return w
+	}
+
+	if w.ring.Cap() == g.maxSize {
+		return nil
+	}
+
+	return g.resize(w, w.ring.Cap()*2)
+}
+
+// resize links a new segment of the given size behind w and makes it the
+// producer's current segment.
+func (g *Growable[T]) resize(w *segment[T], size int) *segment[T] {
+	s := newSegment[T](size, g.opts)
+	w.link(s)
+	g.write.Store(s)
+	g.resizedAt = time.Now()
+	return s
+}
+
+// Pop removes and returns an item from the ring buffer.
+// Returns (zero value, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (g *Growable[T]) Pop() (T, bool) {
+	t, _, ok := g.PopSeq()
+	return t, ok
+}
+
+// PopSeq removes and returns an item and its sequence number from the ring buffer.
+// Returns (zero value, 0, false) if the buffer is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (g *Growable[T]) PopSeq() (T, uint64, bool) {
+	r := g.read.Load()
+	for {
+		val, ok, next := r.pop()
+		if next == nil {
+			if !ok {
+				return val, 0, false
+			}
+
+			seq := g.popped.Load()
+			g.popped.Store(seq + 1)
+			return val, seq, true
+		}
+
+		r = next
+		g.read.Store(r)
+	}
+}
+
+// Stats returns the sequence numbers across all linked rings. It is safe to call
+// from any goroutine.
+func (g *Growable[T]) Stats() Stats {
+	return Stats{
+		Head: g.popped.Load(),
+		Tail: g.pushed.Load(),
+	}
+}
+
+// Cap returns the capacity of the ring currently being pushed to.
+func (g *Growable[T]) Cap() int {
+	return g.write.Load().ring.Cap()
+}
+
+// Len returns the number of items across all linked rings.
+func (g *Growable[T]) Len() int {
+	var n int
+	for s := g.read.Load(); s != nil; s = s.next.Load() {
+		n += s.ring.Len()
+	}
+	return n
+}
+
+// Available returns the number of free slots in the ring currently being pushed
+// to, before it needs to grow.
+func (g *Growable[T]) Available() int {
+	return g.write.Load().ring.Available()
+}
+
+// Flush publishes any pending pushes to the consumer.
+// It is a no-op unless the buffer was created with WithPublishEvery.
+//
+// Only safe to call from a single producer goroutine.
+func (g *Growable[T]) Flush() {
+	g.write.Load().ring.Flush()
+}
+
+// Readable returns a channel that fires when the buffer goes from empty to
+// non-empty. It may fire spuriously, so always Pop to check.
+//
+// Only safe to receive from a single consumer goroutine.
+func (g *Growable[T]) Readable() <-chan struct{} {
+	return g.ready.Readable()
+}
+
+// Writable returns a channel that fires when any of the linked rings goes from
+// full to non-full. It fires spuriously while older rings drain, so always Push
+// to check.
+//
+// Only safe to receive from a single producer goroutine.
+func (g *Growable[T]) Writable() <-chan struct{} {
+	return g.ready.Writable()
+}
@@ -0,0 +1,138 @@
+package grin_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestGrowableGrows(t *testing.T) {
+	buf := grin.NewGrowable[int](4, 16)
+
+	// Rings of 4, 8 and 16 are filled before the max size is reached
+	const total = 4 + 8 + 16
+	for i := 0; i < total; i++ {
+		if !buf.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+
+	if buf.Cap() != 16 {
+		t.Errorf("Cap() = %d, want 16", buf.Cap())
+	}
+	if buf.Len() != total {
+		t.Errorf("Len() = %d, want %d", buf.Len(), total)
+	}
+	if buf.Push(999) {
+		t.Error("Push(999) succeeded when buffer should be full at max size")
+	}
+
+	for i := 0; i < total; i++ {
+		if got, ok := buf.Pop(); !ok || got != i {
+			t.Errorf("Pop() = (%d, %v), want (%d, true)", got, ok, i)
+		}
+	}
+
+	if got, ok := buf.Pop(); ok {
+		t.Errorf("Pop() on empty buffer = (%d, %v), want (0, false)", got, ok)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", buf.Len())
+	}
+}
+
+func TestGrowableInterleaved(t *testing.T) {
+	buf := grin.NewGrowable[int](2, 64)
+
+	next := 0
+	for i := 0; i < 40; i++ {
+		buf.Push(i)
+		if i%3 == 0 {
+			if got, ok := buf.Pop(); !ok || got != next {
+				t.Fatalf("Pop() = (%d, %v), want (%d, true)", got, ok, next)
+			}
+			next++
+		}
+	}
+
+	for ; next < 40; next++ {
+		if got, ok := buf.Pop(); !ok || got != next {
+			t.Fatalf("Pop() = (%d, %v), want (%d, true)", got, ok, next)
+		}
+	}
+}
+
+func TestGrowableShrinkAfter(t *testing.T) {
+	buf := grin.NewGrowable[int](4, 32, grin.WithShrinkAfter(time.Millisecond))
+
+	for i := 0; i < 32; i++ {
+		buf.Push(i)
+	}
+	for i := 0; i < 32; i++ {
+		buf.Pop()
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	buf.Push(1)
+
+	if buf.Cap() != 16 {
+		t.Errorf("Cap() after idle period = %d, want 16", buf.Cap())
+	}
+	if got, ok := buf.Pop(); !ok || got != 1 {
+		t.Errorf("Pop() = (%d, %v), want (1, true)", got, ok)
+	}
+}
+
+func TestGrowableInvalidSize(t *testing.T) {
+	for _, sizes := range [][2]int{{4, 10}, {10, 16}, {32, 16}} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("NewGrowable(%d, %d) should panic", sizes[0], sizes[1])
+				}
+			}()
+			grin.NewGrowable[int](sizes[0], sizes[1])
+		}()
+	}
+}
+
+func TestConcurrentGrowable(t *testing.T) {
+	buf := grin.NewGrowable[int](2, 1024, grin.WithShrinkAfter(time.Microsecond))
+	const numItems = 100000
+	done := make(chan bool, 2)
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for !buf.Push(i) {
+				runtime.Gosched()
+			}
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for {
+				if val, ok := buf.Pop(); ok {
+					if val != i {
+						t.Errorf("got %d, want %d", val, i)
+					}
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+		done <- true
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatal("Test timed out - possible deadlock")
+		}
+	}
+}
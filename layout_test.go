@@ -0,0 +1,274 @@
+package grin
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+// field is a named struct field offset, see assertSeparateLines.
+type field struct {
+	name   string
+	offset uintptr
+}
+
+// assertAligned64 fails if any of the fields could not be used with 64-bit atomic
+// operations on a 32-bit platform.
+func assertAligned64(t *testing.T, fields []field) {
+	t.Helper()
+
+	for _, f := range fields {
+		if f.offset%8 != 0 {
+			t.Errorf("%s is at offset %d, which is not 64-bit aligned", f.name, f.offset)
+		}
+	}
+}
+
+// assertSeparateLines fails if any two consecutive fields, given in offset order,
+// are close enough to share a cache line.
+func assertSeparateLines(t *testing.T, fields []field) {
+	t.Helper()
+
+	for i := 1; i < len(fields); i++ {
+		prev, cur := fields[i-1], fields[i]
+		if cur.offset-prev.offset < cacheLineSize {
+			t.Errorf("%s (offset %d) and %s (offset %d) are less than %d bytes apart",
+				prev.name, prev.offset, cur.name, cur.offset, cacheLineSize)
+		}
+	}
+}
+
+func TestRingBufferLayout(t *testing.T) {
+	var b ringBuffer[int]
+
+	assertSeparateLines(t, []field{
+		{"wait", unsafe.Offsetof(b.wait)},
+		{"head", unsafe.Offsetof(b.head)},
+		{"tail", unsafe.Offsetof(b.tail)},
+		{"nextHead", unsafe.Offsetof(b.nextHead)},
+		{"nextTail", unsafe.Offsetof(b.nextTail)},
+	})
+}
+
+// The futex words sit next to their cursor, so that checking for a parked waiter
+// touches no cache line the publish didn't already.
+func TestRingBufferFutexLayout(t *testing.T) {
+	var b ringBuffer[int]
+
+	for _, f := range []struct {
+		cursor, word, parked field
+	}{
+		{field{"head", unsafe.Offsetof(b.head)}, field{"headFutex", unsafe.Offsetof(b.headFutex)}, field{"producerParked", unsafe.Offsetof(b.producerParked)}},
+		{field{"tail", unsafe.Offsetof(b.tail)}, field{"tailFutex", unsafe.Offsetof(b.tailFutex)}, field{"consumerParked", unsafe.Offsetof(b.consumerParked)}},
+	} {
+		if end := f.parked.offset + 4; end-f.cursor.offset > 16 || f.word.offset < f.cursor.offset {
+			t.Errorf("%s (offset %d) and %s (offset %d) are not next to %s (offset %d)",
+				f.word.name, f.word.offset, f.parked.name, f.parked.offset, f.cursor.name, f.cursor.offset)
+		}
+	}
+}
+
+func TestRingBufferAlignment(t *testing.T) {
+	var b ringBuffer[int]
+
+	assertAligned64(t, []field{
+		{"head", unsafe.Offsetof(b.head)},
+		{"tail", unsafe.Offsetof(b.tail)},
+	})
+}
+
+// TestGOARCH386 re-runs the test suite as a 32-bit binary, which linux/amd64 hosts
+// can execute natively.
+func TestGOARCH386(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 386 run in short mode")
+	}
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("386 binaries are only run on linux/amd64 hosts")
+	}
+
+	cmd := exec.Command("go", "test", "-short", "-count=1", ".")
+	cmd.Env = append(os.Environ(), "GOARCH=386", "CGO_ENABLED=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("GOARCH=386 go test failed: %v\n%s", err, out)
+	}
+}
+
+func TestGrowableLayout(t *testing.T) {
+	var g Growable[int]
+
+	assertSeparateLines(t, []field{
+		{"opts", unsafe.Offsetof(g.opts)},
+		{"popped", unsafe.Offsetof(g.popped)},
+		{"write", unsafe.Offsetof(g.write)},
+	})
+	assertAligned64(t, []field{
+		{"popped", unsafe.Offsetof(g.popped)},
+		{"pushed", unsafe.Offsetof(g.pushed)},
+	})
+}
+
+func TestUnboundedLayout(t *testing.T) {
+	var u Unbounded[int]
+
+	assertSeparateLines(t, []field{
+		{"free", unsafe.Offsetof(u.free)},
+		{"popped", unsafe.Offsetof(u.popped)},
+		{"write", unsafe.Offsetof(u.write)},
+	})
+	assertAligned64(t, []field{
+		{"popped", unsafe.Offsetof(u.popped)},
+		{"pushed", unsafe.Offsetof(u.pushed)},
+	})
+}
+
+func TestDropOldestRingLayout(t *testing.T) {
+	var b dropOldestRing[int]
+
+	assertSeparateLines(t, []field{
+		{"wait", unsafe.Offsetof(b.wait)},
+		{"reading", unsafe.Offsetof(b.reading)},
+		{"tail", unsafe.Offsetof(b.tail)},
+	})
+	assertAligned64(t, []field{
+		{"head", unsafe.Offsetof(b.head)},
+		{"reading", unsafe.Offsetof(b.reading)},
+		{"tail", unsafe.Offsetof(b.tail)},
+		{"dropped", unsafe.Offsetof(b.dropped)},
+	})
+}
+
+func TestSpillLayout(t *testing.T) {
+	var s Spill[int]
+
+	assertSeparateLines(t, []field{
+		{"err", unsafe.Offsetof(s.err)},
+		{"spilled", unsafe.Offsetof(s.spilled)},
+		{"pushed", unsafe.Offsetof(s.pushed)},
+		{"popped", unsafe.Offsetof(s.popped)},
+	})
+	assertAligned64(t, []field{
+		{"spilled", unsafe.Offsetof(s.spilled)},
+		{"pushed", unsafe.Offsetof(s.pushed)},
+		{"popped", unsafe.Offsetof(s.popped)},
+	})
+}
+
+func TestBroadcastLayout(t *testing.T) {
+	var b Broadcast[int]
+
+	assertSeparateLines(t, []field{
+		{"pending", unsafe.Offsetof(b.pending)},
+		{"tail", unsafe.Offsetof(b.tail)},
+		{"readers", unsafe.Offsetof(b.readers)},
+	})
+
+	var r Reader[int]
+	if unsafe.Offsetof(r.b) < cacheLineSize {
+		t.Errorf("Reader fields start at offset %d, want at least %d", unsafe.Offsetof(r.b), cacheLineSize)
+	}
+	if end := unsafe.Offsetof(r.lagged) + 8; unsafe.Sizeof(r)-end < cacheLineSize {
+		t.Errorf("Reader is padded by %d bytes after its fields, want at least %d", unsafe.Sizeof(r)-end, cacheLineSize)
+	}
+	assertAligned64(t, []field{
+		{"cursor", unsafe.Offsetof(r.cursor)},
+		{"reading", unsafe.Offsetof(r.reading)},
+		{"lagged", unsafe.Offsetof(r.lagged)},
+	})
+}
+
+func TestDisruptorLayout(t *testing.T) {
+	var d Disruptor[int]
+
+	assertSeparateLines(t, []field{
+		{"gate", unsafe.Offsetof(d.gate)},
+		{"cursor", unsafe.Offsetof(d.cursor) + unsafe.Offsetof(d.cursor.value)},
+		{"next", unsafe.Offsetof(d.next)},
+	})
+
+	var s Sequence
+	if unsafe.Offsetof(s.value) < cacheLineSize || unsafe.Sizeof(s)-unsafe.Offsetof(s.value) < cacheLineSize {
+		t.Errorf("Sequence value at offset %d of %d is not padded by a full cache line", unsafe.Offsetof(s.value), unsafe.Sizeof(s))
+	}
+	assertAligned64(t, []field{{"value", unsafe.Offsetof(s.value)}})
+}
+
+func TestLaneLayout(t *testing.T) {
+	var l Lane[int]
+
+	assertSeparateLines(t, []field{
+		{"weight", unsafe.Offsetof(l.weight)},
+		{"rejected", unsafe.Offsetof(l.rejected)},
+		{"waiting", unsafe.Offsetof(l.waiting)},
+	})
+	assertAligned64(t, []field{
+		{"rejected", unsafe.Offsetof(l.rejected)},
+	})
+}
+
+func TestAckedLayout(t *testing.T) {
+	var a Acked[int]
+
+	assertSeparateLines(t, []field{
+		{"wait", unsafe.Offsetof(a.wait)},
+		{"committed", unsafe.Offsetof(a.committed)},
+		{"tail", unsafe.Offsetof(a.tail)},
+		{"read", unsafe.Offsetof(a.read)},
+	})
+	assertAligned64(t, []field{
+		{"committed", unsafe.Offsetof(a.committed)},
+		{"tail", unsafe.Offsetof(a.tail)},
+	})
+}
+
+func TestLogLayout(t *testing.T) {
+	var l Log[int]
+
+	assertSeparateLines(t, []field{
+		{"mask", unsafe.Offsetof(l.mask)},
+		{"read", unsafe.Offsetof(l.read)},
+		{"tail", unsafe.Offsetof(l.tail)},
+	})
+	assertAligned64(t, []field{
+		{"read", unsafe.Offsetof(l.read)},
+		{"tail", unsafe.Offsetof(l.tail)},
+		{"floor", unsafe.Offsetof(l.floor)},
+	})
+}
+
+func TestShardLayout(t *testing.T) {
+	var s Shard[int]
+
+	assertSeparateLines(t, []field{
+		{"ring", unsafe.Offsetof(s.ring)},
+		{"rejected", unsafe.Offsetof(s.rejected)},
+	})
+	assertAligned64(t, []field{{"rejected", unsafe.Offsetof(s.rejected)}})
+}
+
+func TestFanInLayout(t *testing.T) {
+	var l FanInLane[int]
+	assertSeparateLines(t, []field{
+		{"bit", unsafe.Offsetof(l.bit)},
+		{"active", unsafe.Offsetof(l.active)},
+	})
+
+	var f FanIn[int]
+	assertSeparateLines(t, []field{
+		{"lanes", unsafe.Offsetof(f.lanes)},
+		{"current", unsafe.Offsetof(f.current)},
+	})
+}
+
+func TestMergeLaneLayout(t *testing.T) {
+	var l MergeLane[int]
+
+	assertSeparateLines(t, []field{
+		{"drained", unsafe.Offsetof(l.drained)},
+		{"watermark", unsafe.Offsetof(l.watermark)},
+	})
+	assertAligned64(t, []field{{"watermark", unsafe.Offsetof(l.watermark)}})
+}
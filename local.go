@@ -0,0 +1,211 @@
+package grin
+
+import "iter"
+
+// Local is a ring buffer for use within a single goroutine, such as the pending
+// work queue of an event loop. It has the same API as RingBuffer but uses plain
+// loads and stores instead of atomics, so it must never be shared between goroutines.
+type Local[T any] struct {
+	store      []T
+	mask       uint64
+	clearOnPop bool
+
+	// Plain copies of the readiness watch flags, so Push and Pop only check for
+	// transitions with an ordinary load once a channel has been asked for
+	watchReadable bool
+	watchWritable bool
+
+	head  uint64
+	tail  uint64
+	ready *readiness
+}
+
+var _ RingBuffer[int] = (*Local[int])(nil)
+
+// NewLocal creates a new single goroutine ring buffer with the specified size.
+// Size must be a power of 2, otherwise it panics. WithClearOnPop is honoured,
+// publication options have no effect as there is nothing to publish.
+func NewLocal[T any](size int, opts ...Option) *Local[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Local[T]{
+		store:      make([]T, size),
+		mask:       uint64(size) - 1,
+		clearOnPop: o.clearOnPop,
+		ready:      newReadiness(),
+	}
+}
+
+// Push adds an item to the ring buffer.
+// Returns false if the buffer is full.
+func (l *Local[T]) Push(t T) bool {
+	tail := l.tail
+	if tail-l.head == uint64(len(l.store)) {
+		return false
+	}
+
+	l.store[tail&l.mask] = t
+	l.tail = tail + 1
+
+	if l.watchReadable && tail == l.head {
+		signal(l.ready.readable)
+	}
+	return true
+}
+
+// Pop removes and returns an item from the ring buffer.
+// Returns (zero value, false) if the buffer is empty.
+func (l *Local[T]) Pop() (T, bool) {
+	var zero T
+	head := l.head
+	if l.tail == head {
+		return zero, false
+	}
+
+	slot := &l.store[head&l.mask]
+	val := *slot
+	if l.clearOnPop {
+		*slot = zero
+	}
+	l.head = head + 1
+
+	if l.watchWritable && l.tail-head == uint64(len(l.store)) {
+		signal(l.ready.writable)
+	}
+	return val, true
+}
+
+// PushBatch adds as many items from ts as fit and returns how many it pushed.
+func (l *Local[T]) PushBatch(ts []T) int {
+	tail := l.tail
+	n := min(len(ts), len(l.store)-int(tail-l.head))
+	if n == 0 {
+		return 0
+	}
+
+	i := int(tail & l.mask)
+	c := copy(l.store[i:], ts[:n])
+	copy(l.store, ts[c:n])
+	l.tail = tail + uint64(n)
+
+	if l.watchReadable && tail == l.head {
+		signal(l.ready.readable)
+	}
+	return n
+}
+
+// PopBatch removes up to len(dst) items into dst and returns how many it popped.
+func (l *Local[T]) PopBatch(dst []T) int {
+	head := l.head
+	n := min(len(dst), int(l.tail-head))
+	if n == 0 {
+		return 0
+	}
+
+	i := int(head & l.mask)
+	c := copy(dst[:n], l.store[i:])
+	copy(dst[c:n], l.store)
+	if l.clearOnPop {
+		clear(l.store[i : i+c])
+		clear(l.store[:n-c])
+	}
+	l.head = head + uint64(n)
+
+	if l.watchWritable && l.tail-head == uint64(len(l.store)) {
+		signal(l.ready.writable)
+	}
+	return n
+}
+
+// Peek returns the item Pop would return without removing it.
+// Returns (zero value, false) if the buffer is empty.
+func (l *Local[T]) Peek() (T, bool) {
+	if l.tail == l.head {
+		var zero T
+		return zero, false
+	}
+	return l.store[l.head&l.mask], true
+}
+
+// All returns an iterator over the buffered items from oldest to newest, without
+// removing them. The buffer must not be popped from until iteration stops.
+func (l *Local[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for seq := l.head; seq != l.tail; seq++ {
+			if !yield(l.store[seq&l.mask]) {
+				return
+			}
+		}
+	}
+}
+
+// Drain returns an iterator that pops items until the buffer is empty, including
+// any pushed during iteration.
+func (l *Local[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			val, ok := l.Pop()
+			if !ok || !yield(val) {
+				return
+			}
+		}
+	}
+}
+
+// PushSeq adds an item to the ring buffer and returns its sequence number.
+// Returns false if the buffer is full.
+func (l *Local[T]) PushSeq(t T) (uint64, bool) {
+	seq := l.tail
+	return seq, l.Push(t)
+}
+
+// PopSeq removes and returns an item and its sequence number from the ring buffer.
+// Returns (zero value, 0, false) if the buffer is empty.
+func (l *Local[T]) PopSeq() (T, uint64, bool) {
+	seq := l.head
+	t, ok := l.Pop()
+	if !ok {
+		return t, 0, false
+	}
+	return t, seq, true
+}
+
+func (l *Local[T]) Stats() Stats {
+	return Stats{Head: l.head, Tail: l.tail}
+}
+
+func (l *Local[T]) Cap() int {
+	return len(l.store)
+}
+
+func (l *Local[T]) Len() int {
+	return int(l.tail - l.head)
+}
+
+func (l *Local[T]) Available() int {
+	return l.Cap() - l.Len()
+}
+
+// Flush is a no-op, pushes are visible to Pop immediately.
+func (l *Local[T]) Flush() {}
+
+// Readable returns a channel that fires when the buffer goes from empty to
+// non-empty, for use in the owning goroutine's select loop.
+func (l *Local[T]) Readable() <-chan struct{} {
+	l.watchReadable = true
+	return l.ready.readable
+}
+
+// Writable returns a channel that fires when the buffer goes from full to
+// non-full, for use in the owning goroutine's select loop.
+func (l *Local[T]) Writable() <-chan struct{} {
+	l.watchWritable = true
+	return l.ready.writable
+}
@@ -0,0 +1,211 @@
+package grin_test
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestLocalPushPop(t *testing.T) {
+	buf := grin.NewLocal[int](4)
+
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if !buf.Push(round*10 + i) {
+				t.Fatalf("Round %d: Push(%d) failed", round, round*10+i)
+			}
+		}
+
+		if buf.Push(999) {
+			t.Errorf("Round %d: Push(999) succeeded when buffer should be full", round)
+		}
+
+		for i := 0; i < 4; i++ {
+			want := round*10 + i
+			if got, ok := buf.Pop(); !ok || got != want {
+				t.Errorf("Round %d: Pop() = (%d, %v), want (%d, true)", round, got, ok, want)
+			}
+		}
+
+		if got, ok := buf.Pop(); ok {
+			t.Errorf("Round %d: Pop() on empty buffer = (%d, %v), want (0, false)", round, got, ok)
+		}
+	}
+}
+
+func TestLocalObservabilityMethods(t *testing.T) {
+	buf := grin.NewLocal[int](8)
+
+	buf.Push(1)
+	buf.Push(2)
+	buf.Push(3)
+	buf.Pop()
+
+	if buf.Cap() != 8 {
+		t.Errorf("Cap() = %d, want 8", buf.Cap())
+	}
+	if buf.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", buf.Len())
+	}
+	if buf.Available() != 6 {
+		t.Errorf("Available() = %d, want 6", buf.Available())
+	}
+}
+
+func TestLocalPowerOfTwoSize(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("NewLocal(10) should panic for non-power-of-two size")
+		}
+	}()
+
+	grin.NewLocal[int](10)
+}
+
+func TestLocalPopReleasesReference(t *testing.T) {
+	buf := grin.NewLocal[*bigStruct](8)
+
+	wp := pushWeak(buf)
+	if _, ok := buf.Pop(); !ok {
+		t.Fatal("Pop() failed")
+	}
+
+	runtime.GC()
+	if wp.Value() != nil {
+		t.Error("popped value is still reachable through the buffer")
+	}
+}
+
+func TestLocalZeroAllocs(t *testing.T) {
+	buf := grin.NewLocal[int](64)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < 64; i++ {
+			buf.Push(i)
+		}
+		for i := 0; i < 64; i++ {
+			buf.Pop()
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Push/Pop allocated %v times per run, want 0", allocs)
+	}
+
+	batch := make([]int, 64)
+	allocs = testing.AllocsPerRun(100, func() {
+		buf.PushBatch(batch)
+		buf.Peek()
+		for range buf.All() {
+		}
+		for range buf.Drain() {
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Batches, Peek and iterators allocated %v times per run, want 0", allocs)
+	}
+}
+
+func TestLocalBatch(t *testing.T) {
+	buf := grin.NewLocal[int](8)
+	readable := buf.Readable()
+
+	// Start part way round so that both batches wrap
+	buf.PushBatch([]int{-1, -2, -3, -4, -5})
+	buf.PopBatch(make([]int, 5))
+	<-readable
+
+	if n := buf.PushBatch([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}); n != 8 {
+		t.Fatalf("PushBatch() = %d, want 8", n)
+	}
+	select {
+	case <-readable:
+	default:
+		t.Error("PushBatch() did not fire Readable() on empty to non-empty")
+	}
+	if n := buf.PushBatch([]int{8}); n != 0 {
+		t.Errorf("PushBatch() on a full buffer = %d, want 0", n)
+	}
+
+	got := make([]int, 5)
+	if n := buf.PopBatch(got); n != 5 {
+		t.Fatalf("PopBatch() = %d, want 5", n)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("PopBatch() popped %v, want %v", got, want)
+	}
+
+	if n := buf.PopBatch(got); n != 3 || !slices.Equal(got[:n], []int{5, 6, 7}) {
+		t.Errorf("PopBatch() = %d, %v, want 3, [5 6 7]", n, got[:n])
+	}
+	if n := buf.PopBatch(got); n != 0 {
+		t.Errorf("PopBatch() on an empty buffer = %d, want 0", n)
+	}
+}
+
+func TestLocalPopBatchReleasesReferences(t *testing.T) {
+	buf := grin.NewLocal[*bigStruct](8)
+
+	wp := pushWeak(buf)
+	if n := buf.PopBatch(make([]*bigStruct, 4)); n != 1 {
+		t.Fatalf("PopBatch() = %d, want 1", n)
+	}
+
+	runtime.GC()
+	if wp.Value() != nil {
+		t.Error("popped value is still reachable through the buffer")
+	}
+}
+
+func TestLocalPeek(t *testing.T) {
+	buf := grin.NewLocal[int](4)
+
+	if _, ok := buf.Peek(); ok {
+		t.Error("Peek() on an empty buffer = true, want false")
+	}
+
+	buf.Push(1)
+	buf.Push(2)
+	if v, ok := buf.Peek(); !ok || v != 1 {
+		t.Errorf("Peek() = %d, %v, want 1, true", v, ok)
+	}
+	if buf.Len() != 2 {
+		t.Errorf("Len() = %d after Peek, want 2", buf.Len())
+	}
+}
+
+func TestLocalIterators(t *testing.T) {
+	buf := grin.NewLocal[int](4)
+	for i := 0; i < 6; i++ {
+		if !buf.Push(i) {
+			buf.Pop()
+			buf.Push(i)
+		}
+	}
+
+	if got := slices.Collect(buf.All()); !slices.Equal(got, []int{2, 3, 4, 5}) {
+		t.Errorf("All() = %v, want [2 3 4 5]", got)
+	}
+	if buf.Len() != 4 {
+		t.Errorf("Len() = %d after All, want 4", buf.Len())
+	}
+
+	var got []int
+	for v := range buf.Drain() {
+		got = append(got, v)
+		if v == 3 {
+			break
+		}
+	}
+	if !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("Drain() until 3 = %v, want [2 3]", got)
+	}
+
+	if got := slices.Collect(buf.Drain()); !slices.Equal(got, []int{4, 5}) {
+		t.Errorf("Drain() = %v, want [4 5]", got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Len() = %d after Drain, want 0", buf.Len())
+	}
+}
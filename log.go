@@ -0,0 +1,178 @@
+package grin
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+var (
+	// ErrTruncated is returned by Log.Seek when the sequence has been overwritten.
+	ErrTruncated = errors.New("grin: sequence has been overwritten")
+
+	// ErrNotPushed is returned by Log.Seek when the sequence has not been pushed yet.
+	ErrNotPushed = errors.New("grin: sequence has not been pushed yet")
+)
+
+// testHookLogOverwrite lets tests seek in the window between the producer
+// announcing an overwrite and checking the consumer's position again.
+var testHookLogOverwrite func()
+
+// Log is a SPSC ring buffer that keeps items after they are popped, until the
+// producer needs their slot for a new item. The consumer can Seek back to any
+// retained sequence to replay after a downstream failure, like a Kafka partition
+// held in memory.
+//
+// The producer never overwrites an item the consumer has yet to read, so Push
+// returns false when every slot holds an unread item, including after seeking back.
+type Log[T any] struct {
+	store []T
+	mask  uint64
+	_     [cacheLineSize]byte // Do not remove
+
+	read atomic.Uint64           // Published by the consumer, lowered by Seek
+	_    [cacheLineSize - 8]byte // Do not remove
+
+	tail        atomic.Uint64           // Published by the producer, consumer must use atomic operations to read
+	floor       atomic.Uint64           // Published by the producer, the oldest sequence that is still retained
+	overwriting atomic.Uint64           // Sequence the producer is about to overwrite plus one, zero when idle
+	_           [cacheLineSize - 8]byte // Do not remove
+}
+
+// NewLog creates a new replay log that retains the last size items. Size must be
+// a power of 2, otherwise it panics. Items stay reachable until overwritten, so
+// WithClearOnPop has no effect.
+func NewLog[T any](size int) *Log[T] {
+	if size&(size-1) != 0 {
+		panic("size must be power of two")
+	}
+
+	return &Log[T]{
+		store: make([]T, size),
+		mask:  uint64(size) - 1,
+	}
+}
+
+// Push adds an item to the log, overwriting the oldest retained item once full.
+// Returns false if that item has not been read yet (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (l *Log[T]) Push(t T) bool {
+	_, ok := l.PushSeq(t)
+	return ok
+}
+
+// PushSeq adds an item to the log and returns its sequence number.
+// Returns false if the oldest retained item has not been read yet (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (l *Log[T]) PushSeq(t T) (uint64, bool) {
+	tail := l.tail.Load()
+	size := uint64(len(l.store))
+
+	if tail-l.read.Load() >= size {
+		return tail, false
+	}
+
+	if tail >= size {
+		// Announce the overwrite before checking again, so that a concurrent Seek
+		// either waits for us to decide or stops us overwriting the item. The floor
+		// is only raised once the overwrite is certain.
+		oldest := tail - size
+		l.overwriting.Store(oldest + 1)
+		if testHookLogOverwrite != nil {
+			testHookLogOverwrite()
+		}
+		if tail-l.read.Load() >= size {
+			l.overwriting.Store(0)
+			return tail, false
+		}
+
+		l.floor.Store(oldest + 1)
+		l.overwriting.Store(0)
+	}
+
+	l.store[tail&l.mask] = t
+	l.tail.Store(tail + 1)
+	return tail, true
+}
+
+// Pop returns the next item in the log, which stays retained for replay.
+// Returns (zero value, false) if every item has been read (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (l *Log[T]) Pop() (T, bool) {
+	t, _, ok := l.PopSeq()
+	return t, ok
+}
+
+// PopSeq returns the next item in the log and its sequence number.
+// Returns (zero value, 0, false) if every item has been read (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (l *Log[T]) PopSeq() (T, uint64, bool) {
+	read := l.read.Load()
+	if read == l.tail.Load() {
+		var zero T
+		return zero, 0, false
+	}
+
+	t := l.store[read&l.mask]
+	l.read.Store(read + 1)
+	return t, read, true
+}
+
+// Seek moves the consumer so that the next Pop returns the item with the given
+// sequence. Seeking back replays retained items, seeking forward skips items.
+// Returns ErrTruncated if the item has been overwritten, or ErrNotPushed if it
+// is past the last pushed item, in which case the consumer does not move.
+//
+// Only safe to call from a single consumer goroutine.
+func (l *Log[T]) Seek(seq uint64) error {
+	if seq > l.tail.Load() {
+		return ErrNotPushed
+	}
+
+	prev := l.read.Load()
+	l.read.Store(seq)
+
+	// Check after publishing read, see PushSeq. If the producer is deciding whether
+	// to overwrite the item, wait for it to raise the floor or back off.
+	for l.overwriting.Load() == seq+1 {
+		runtime.Gosched()
+	}
+	if seq < l.floor.Load() {
+		l.read.Store(prev)
+		return ErrTruncated
+	}
+	return nil
+}
+
+// Oldest returns the sequence of the oldest retained item, which Seek can return
+// to.
+func (l *Log[T]) Oldest() uint64 {
+	return l.floor.Load()
+}
+
+func (l *Log[T]) Cap() int {
+	return len(l.store)
+}
+
+// Len returns the number of items that have not been read yet.
+func (l *Log[T]) Len() int {
+	tail := l.tail.Load()
+	read := l.read.Load()
+	return int(tail - read)
+}
+
+func (l *Log[T]) Available() int {
+	return l.Cap() - l.Len()
+}
+
+// Stats returns the read position and tail. It is safe to call from any goroutine.
+func (l *Log[T]) Stats() Stats {
+	return Stats{
+		Head: l.read.Load(),
+		Tail: l.tail.Load(),
+	}
+}
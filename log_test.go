@@ -0,0 +1,201 @@
+package grin_test
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestLogReplay(t *testing.T) {
+	l := grin.NewLog[int](4)
+	for i := 0; i < 3; i++ {
+		l.Push(i)
+	}
+	for i := 0; i < 3; i++ {
+		l.Pop()
+	}
+
+	if err := l.Seek(1); err != nil {
+		t.Fatalf("Seek(1) error = %v, want nil", err)
+	}
+	for want := 1; want < 3; want++ {
+		if v, seq, ok := l.PopSeq(); !ok || v != want || seq != uint64(want) {
+			t.Errorf("PopSeq() = (%d, %d, %v), want (%d, %d, true)", v, seq, ok, want, want)
+		}
+	}
+	if _, ok := l.Pop(); ok {
+		t.Error("Pop() after replaying should return false")
+	}
+}
+
+func TestLogTruncated(t *testing.T) {
+	l := grin.NewLog[int](4)
+	for i := 0; i < 6; i++ {
+		if !l.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+		l.Pop()
+	}
+
+	if l.Oldest() != 2 {
+		t.Errorf("Oldest() = %d, want 2", l.Oldest())
+	}
+	if err := l.Seek(1); !errors.Is(err, grin.ErrTruncated) {
+		t.Errorf("Seek(1) error = %v, want %v", err, grin.ErrTruncated)
+	}
+	if err := l.Seek(7); !errors.Is(err, grin.ErrNotPushed) {
+		t.Errorf("Seek(7) error = %v, want %v", err, grin.ErrNotPushed)
+	}
+
+	// A failed Seek leaves the consumer where it was
+	if l.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", l.Len())
+	}
+
+	if err := l.Seek(2); err != nil {
+		t.Fatalf("Seek(2) error = %v, want nil", err)
+	}
+	if v, _ := l.Pop(); v != 2 {
+		t.Errorf("Pop() = %d, want 2", v)
+	}
+}
+
+func TestLogNeverOverwritesUnread(t *testing.T) {
+	l := grin.NewLog[int](4)
+	for i := 0; i < 4; i++ {
+		l.Push(i)
+	}
+	if l.Push(4) {
+		t.Error("Push() should fail while every item is unread")
+	}
+
+	l.Pop()
+	if !l.Push(4) {
+		t.Error("Push() should overwrite the item that has been read")
+	}
+
+	// Seeking back to the oldest item makes it unread again
+	for l.Len() > 0 {
+		l.Pop()
+	}
+	if err := l.Seek(l.Oldest()); err != nil {
+		t.Fatalf("Seek() error = %v, want nil", err)
+	}
+	if l.Push(5) {
+		t.Error("Push() should fail after seeking back over every item")
+	}
+}
+
+// seekOrTruncated seeks to seq and checks that Seek only reports ErrTruncated once
+// the item really has been overwritten, and otherwise replays it next.
+func seekOrTruncated(t *testing.T, l *grin.Log[int], seq uint64) bool {
+	err := l.Seek(seq)
+	switch {
+	case errors.Is(err, grin.ErrTruncated):
+		if oldest := l.Oldest(); oldest <= seq {
+			t.Errorf("Seek(%d) = ErrTruncated, but Oldest() = %d still retains it", seq, oldest)
+			return false
+		}
+	case err != nil:
+		t.Errorf("Seek(%d) error = %v", seq, err)
+		return false
+	default:
+		if v, got, ok := l.PopSeq(); !ok || got != seq || v != int(seq) {
+			t.Errorf("PopSeq() after Seek(%d) = (%d, %d, %v), want (%d, %d, true)", seq, v, got, ok, seq, seq)
+			return false
+		}
+	}
+	return true
+}
+
+func TestLogConcurrentSeek(t *testing.T) {
+	const n = 100000
+	l := grin.NewLog[int](64)
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < n; i++ {
+			for !l.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	go func() {
+		defer func() { done <- true }()
+
+		var last, replayed uint64
+		for last < n-1 {
+			v, seq, ok := l.PopSeq()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if v != int(seq) {
+				t.Errorf("PopSeq() = (%d, %d), item does not match its sequence", v, seq)
+				return
+			}
+			last = seq
+
+			// Replay the last few items now and then, racing the producer
+			if seq%100 == 0 && seq > replayed {
+				replayed = seq
+				if !seekOrTruncated(t, l, seq-8) {
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+}
+
+func TestLogConcurrentSeekLastRead(t *testing.T) {
+	const n = 100000
+	l := grin.NewLog[int](2)
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < n; i++ {
+			for !l.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	go func() {
+		defer func() { done <- true }()
+
+		// Seek back to the item just read while the producer tries to overwrite it
+		for {
+			v, seq, ok := l.PopSeq()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if v != int(seq) {
+				t.Errorf("PopSeq() = (%d, %d), item does not match its sequence", v, seq)
+				return
+			}
+			if seq == n-1 {
+				return
+			}
+			if !seekOrTruncated(t, l, seq) {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+}
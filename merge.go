@@ -0,0 +1,206 @@
+package grin
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// MergeLane is one input of an OrderedMerge. Its items must be pushed in key order.
+type MergeLane[T any] struct {
+	src interface{ Pop() (T, bool) }
+
+	// Owned by the consumer
+	head    T
+	key     int64               // Key of head
+	last    int64               // Key of the last item popped, no earlier key can follow it
+	bound   int64               // Lowest key that may still arrive while the lane is empty
+	index   int                 // Position in the lane order, used to break ties
+	drained bool                // Closed and empty
+	_       [cacheLineSize]byte // Do not remove
+
+	watermark atomic.Int64            // Published by the producer
+	closed    atomic.Bool             // Published by the producer
+	_         [cacheLineSize - 9]byte // Do not remove
+}
+
+// Advance promises that no item with a key below w will be pushed to the lane
+// after this call, so that the merge doesn't wait on the lane for earlier items.
+// Call it, for example, on heartbeats from a quiet venue.
+//
+// Only safe to call from the lane's producer goroutine, after pushing.
+func (l *MergeLane[T]) Advance(w int64) {
+	l.watermark.Store(w)
+}
+
+// Close promises that nothing more will be pushed to the lane.
+//
+// Only safe to call from the lane's producer goroutine, after pushing.
+func (l *MergeLane[T]) Close() {
+	l.closed.Store(true)
+}
+
+// fill pops the lane's next item into head, or updates bound if it is empty.
+func (l *MergeLane[T]) fill(key func(T) int64) bool {
+	// Load before popping, so every item pushed before them is visible
+	watermark := l.watermark.Load()
+	closed := l.closed.Load()
+
+	if t, ok := l.src.Pop(); ok {
+		l.head, l.key = t, key(t)
+		return true
+	}
+
+	l.drained = closed
+	l.bound = max(l.last, watermark)
+	return false
+}
+
+// OrderedMerge merges several rings, each sorted by key, into one globally ordered
+// stream, such as per-venue feeds sorted by exchange timestamp. It keeps a heap over
+// the head of each lane and only waits on an empty lane while its watermark says an
+// earlier item may still arrive.
+type OrderedMerge[T any] struct {
+	key   func(T) int64
+	heap  []*MergeLane[T] // Lanes with a head, ordered by key
+	empty []*MergeLane[T] // Lanes without a head
+	lanes int
+}
+
+// NewOrderedMerge creates an empty merge ordered by key.
+func NewOrderedMerge[T any](key func(T) int64) *OrderedMerge[T] {
+	return &OrderedMerge[T]{key: key}
+}
+
+// Add registers a ring, usually a RingBuffer, whose items are sorted by key. The
+// returned lane is used by the ring's producer to advance its watermark.
+//
+// Only safe to call from the consumer goroutine.
+func (m *OrderedMerge[T]) Add(src interface{ Pop() (T, bool) }) *MergeLane[T] {
+	l := &MergeLane[T]{
+		src:   src,
+		last:  math.MinInt64,
+		bound: math.MinInt64,
+		index: m.lanes,
+	}
+	l.watermark.Store(math.MinInt64)
+
+	m.lanes++
+	m.empty = append(m.empty, l)
+	return l
+}
+
+// Pop removes and returns the item with the lowest key across every lane.
+// Returns (zero value, false) if every lane is empty, or if an empty lane may still
+// receive an item with a lower key than any available (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (m *OrderedMerge[T]) Pop() (T, bool) {
+	m.fill()
+
+	var zero T
+	if len(m.heap) == 0 {
+		return zero, false
+	}
+
+	top := m.heap[0]
+	for _, l := range m.empty {
+		if top.key > l.bound {
+			return zero, false
+		}
+	}
+
+	val := top.head
+	top.head = zero
+	top.last = top.key
+
+	if top.fill(m.key) {
+		m.down(0)
+	} else {
+		m.remove()
+		if !top.drained {
+			m.empty = append(m.empty, top)
+		}
+	}
+	return val, true
+}
+
+// fill moves empty lanes that now have an item onto the heap, and forgets drained
+// lanes.
+func (m *OrderedMerge[T]) fill() {
+	n := 0
+	for _, l := range m.empty {
+		switch {
+		case l.fill(m.key):
+			m.heap = append(m.heap, l)
+			m.up(len(m.heap) - 1)
+		case !l.drained:
+			m.empty[n] = l
+			n++
+		}
+	}
+	clear(m.empty[n:])
+	m.empty = m.empty[:n]
+}
+
+// Len returns the number of items across every lane.
+func (m *OrderedMerge[T]) Len() int {
+	n := len(m.heap)
+	for _, l := range m.heap {
+		if r, ok := l.src.(interface{ Len() int }); ok {
+			n += r.Len()
+		}
+	}
+	for _, l := range m.empty {
+		if r, ok := l.src.(interface{ Len() int }); ok {
+			n += r.Len()
+		}
+	}
+	return n
+}
+
+func (m *OrderedMerge[T]) less(i, j int) bool {
+	a, b := m.heap[i], m.heap[j]
+	if a.key != b.key {
+		return a.key < b.key
+	}
+	return a.index < b.index
+}
+
+func (m *OrderedMerge[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !m.less(i, parent) {
+			return
+		}
+		m.heap[i], m.heap[parent] = m.heap[parent], m.heap[i]
+		i = parent
+	}
+}
+
+func (m *OrderedMerge[T]) down(i int) {
+	for {
+		least := i
+		if l := 2*i + 1; l < len(m.heap) && m.less(l, least) {
+			least = l
+		}
+		if r := 2*i + 2; r < len(m.heap) && m.less(r, least) {
+			least = r
+		}
+		if least == i {
+			return
+		}
+		m.heap[i], m.heap[least] = m.heap[least], m.heap[i]
+		i = least
+	}
+}
+
+// remove drops the top of the heap.
+func (m *OrderedMerge[T]) remove() {
+	last := len(m.heap) - 1
+	m.heap[0] = m.heap[last]
+	m.heap[last] = nil
+	m.heap = m.heap[:last]
+	if last > 0 {
+		m.down(0)
+	}
+}
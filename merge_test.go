@@ -0,0 +1,160 @@
+package grin_test
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+type quote struct {
+	venue int
+	ts    int64
+}
+
+func quoteTime(q quote) int64 { return q.ts }
+
+func drainMerge(m *grin.OrderedMerge[quote]) []int64 {
+	var got []int64
+	for {
+		q, ok := m.Pop()
+		if !ok {
+			return got
+		}
+		got = append(got, q.ts)
+	}
+}
+
+func TestOrderedMergeSorted(t *testing.T) {
+	m := grin.NewOrderedMerge(quoteTime)
+
+	venues := [][]int64{{1, 4, 7, 10}, {2, 5, 8}, {3, 6, 9, 11, 12}}
+	for v, times := range venues {
+		buf := grin.New[quote](16)
+		for _, ts := range times {
+			buf.Push(quote{v, ts})
+		}
+		m.Add(buf).Close()
+	}
+
+	got := drainMerge(m)
+	want := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	if !slices.Equal(got, want) {
+		t.Errorf("Merged %v, want %v", got, want)
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", m.Len())
+	}
+}
+
+func TestOrderedMergeWaitsOnWatermark(t *testing.T) {
+	m := grin.NewOrderedMerge(quoteTime)
+	fast := grin.New[quote](16)
+	slow := grin.New[quote](16)
+	m.Add(fast)
+	slowLane := m.Add(slow)
+
+	fast.Push(quote{0, 10})
+	fast.Push(quote{0, 20})
+
+	// The slow venue may still send something earlier than 10
+	if _, ok := m.Pop(); ok {
+		t.Fatal("Pop() should wait while an empty lane's watermark is behind")
+	}
+
+	slowLane.Advance(15)
+	if got := drainMerge(m); !slices.Equal(got, []int64{10}) {
+		t.Errorf("Merged %v after the watermark moved to 15, want [10]", got)
+	}
+
+	slow.Push(quote{1, 17})
+	if got := drainMerge(m); !slices.Equal(got, []int64{17}) {
+		t.Errorf("Merged %v, want [17] as 20 may still be preceded on the slow lane", got)
+	}
+
+	slowLane.Close()
+	if got := drainMerge(m); !slices.Equal(got, []int64{20}) {
+		t.Errorf("Merged %v after the slow lane closed, want [20]", got)
+	}
+}
+
+func TestOrderedMergeLastKeyIsBound(t *testing.T) {
+	m := grin.NewOrderedMerge(quoteTime)
+	a := grin.New[quote](16)
+	b := grin.New[quote](16)
+	m.Add(a)
+	m.Add(b)
+
+	a.Push(quote{0, 5})
+	b.Push(quote{1, 1})
+	b.Push(quote{1, 6})
+
+	// Once 5 has been popped from a, nothing earlier can follow it there, but 6
+	// on b must wait as a may still send something between 5 and 6
+	if got := drainMerge(m); !slices.Equal(got, []int64{1, 5}) {
+		t.Errorf("Merged %v, want [1 5]", got)
+	}
+
+	a.Push(quote{0, 6})
+	for _, venue := range []int{0, 1} {
+		if q, ok := m.Pop(); !ok || q.ts != 6 || q.venue != venue {
+			t.Errorf("Pop() = (%+v, %v), want venue %d at 6 with ties broken by lane order", q, ok, venue)
+		}
+	}
+}
+
+func TestOrderedMergeConcurrent(t *testing.T) {
+	const (
+		venues = 4
+		n      = 20000
+	)
+	m := grin.NewOrderedMerge(quoteTime)
+
+	for v := 0; v < venues; v++ {
+		buf := grin.New[quote](64)
+		lane := m.Add(buf)
+
+		go func() {
+			rng := rand.New(rand.NewPCG(uint64(v), 0))
+			var ts int64
+			for i := 0; i < n; i++ {
+				ts += rng.Int64N(10)
+				for !buf.Push(quote{v, ts}) {
+					runtime.Gosched()
+				}
+				// Heartbeat now and then, as a quiet venue would
+				if i%16 == 0 {
+					lane.Advance(ts)
+				}
+			}
+			lane.Close()
+		}()
+	}
+
+	done := make(chan bool)
+	go func() {
+		var last int64
+		for total := 0; total < venues*n; {
+			q, ok := m.Pop()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if q.ts < last {
+				t.Errorf("Merged %d after %d, want non-decreasing", q.ts, last)
+			}
+			last = q.ts
+			total++
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+}
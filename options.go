@@ -0,0 +1,112 @@
+package grin
+
+import "time"
+
+// Option configures a ring buffer created by New.
+type Option func(*options)
+
+type options struct {
+	publishEvery int
+	clearOnPop   bool
+	shrinkAfter  time.Duration
+	spillFile    string
+
+	starvationLimit int
+	ttl             time.Duration
+	visibility      time.Duration
+
+	wait    WaitStrategy
+	eventFD *EventFD
+
+	// readiness is shared by the segments of a Growable
+	readiness *readiness
+}
+
+func defaultOptions() options {
+	return options{
+		publishEvery: 1,
+		clearOnPop:   true,
+	}
+}
+
+// WithPublishEvery makes the producer publish tail only once every n pushes, or
+// when Flush is called, and the consumer publish head only once every n pops, or
+// when it finds the buffer empty. Each side is therefore never more than n-1 items
+// stale from the other's point of view, in exchange for far fewer release stores.
+//
+// The default of 1 publishes on every Push and Pop. n must be between 1 and the
+// buffer size, otherwise New panics.
+func WithPublishEvery(n int) Option {
+	return func(o *options) {
+		o.publishEvery = n
+	}
+}
+
+// WithClearOnPop controls whether Pop zeroes the slot it reads from. Clearing is
+// enabled by default so that a buffer of pointers, slices or maps does not keep
+// popped values reachable. It can be disabled for pointer-free types, where the
+// stale copy is harmless and the extra store is wasted.
+func WithClearOnPop(enabled bool) Option {
+	return func(o *options) {
+		o.clearOnPop = enabled
+	}
+}
+
+// WithShrinkAfter lets a Growable halve its capacity, down to its initial size,
+// once it has not been resized for d and the producer finds it empty. Growable
+// buffers never shrink by default.
+func WithShrinkAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.shrinkAfter = d
+	}
+}
+
+// WithSpillFile sets the file that a Spill created with FullSpillDisk writes
+// overflowing items to. The file is truncated on creation and removed by Close.
+func WithSpillFile(path string) Option {
+	return func(o *options) {
+		o.spillFile = path
+	}
+}
+
+// WithStarvationLimit makes a PriorityRing with StrictPriority serve a waiting
+// lower priority lane once higher priority lanes have been served n times in a row
+// while it waited. Lower priority lanes can starve by default.
+func WithStarvationLimit(n int) Option {
+	return func(o *options) {
+		o.starvationLimit = n
+	}
+}
+
+// WithWaitStrategy makes the ring notify w every time it publishes a Push or Pop,
+// so that goroutines waiting on it through w, such as a Selector, are woken.
+func WithWaitStrategy(w WaitStrategy) Option {
+	return func(o *options) {
+		o.wait = w
+	}
+}
+
+// WithEventFD makes the ring signal e whenever it goes from empty to non-empty, so
+// that the consumer can wait on it with epoll. It is a no-op outside of Linux.
+func WithEventFD(e *EventFD) Option {
+	return func(o *options) {
+		o.eventFD = e
+	}
+}
+
+// WithTTL gives every item pushed to an Expiring with Push a deadline of d after
+// the push. Items never expire by default, unless pushed with PushDeadline.
+func WithTTL(d time.Duration) Option {
+	return func(o *options) {
+		o.ttl = d
+	}
+}
+
+// WithVisibilityTimeout makes an Acked redeliver its in-flight items once the
+// oldest of them has gone unacknowledged for d. Items are only redelivered by
+// Nack or Rewind by default.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.visibility = d
+	}
+}
@@ -0,0 +1,79 @@
+package grin
+
+import (
+	"context"
+	"testing"
+)
+
+// hookOverflow runs beforePush ahead of every push to the overflow it wraps.
+type hookOverflow[T any] struct {
+	overflow[T]
+	beforePush func()
+}
+
+func (h *hookOverflow[T]) push(t T) error {
+	if h.beforePush != nil {
+		h.beforePush()
+	}
+	return h.overflow.push(t)
+}
+
+// countingWait counts calls to Notify.
+type countingWait struct {
+	notified int
+}
+
+func (w *countingWait) Wait(ctx context.Context, ready func() bool) error {
+	return nil
+}
+
+func (w *countingWait) Notify() {
+	w.notified++
+}
+
+// The consumer drains the ring and the overflow between the producer seeing items
+// spilled and it pushing to the overflow, so nothing is ever pushed to the ring to
+// signal the consumer.
+func TestSpillReadableAfterDrain(t *testing.T) {
+	w := &countingWait{}
+	s, err := NewSpill[int](2, FullSpillMemory, WithWaitStrategy(w))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readable := s.Readable()
+
+	for i := 0; i < 3; i++ {
+		s.Push(i)
+	}
+	<-readable
+
+	h := &hookOverflow[int]{overflow: s.overflow}
+	s.overflow = h
+	h.beforePush = func() {
+		h.beforePush = nil
+		for i := 0; i < 3; i++ {
+			if v, ok := s.Pop(); !ok || v != i {
+				t.Fatalf("Pop() = %d, %v, want %d, true", v, ok, i)
+			}
+		}
+		if _, ok := s.Pop(); ok {
+			t.Fatal("Pop() = true after draining, want false")
+		}
+		w.notified = 0
+	}
+
+	// The consumer now waits for readable while the producer pushes to the overflow
+	s.Push(3)
+
+	select {
+	case <-readable:
+	default:
+		t.Fatal("Readable() did not fire when the overflow went from empty to non-empty")
+	}
+	if w.notified == 0 {
+		t.Error("WaitStrategy was not notified when the overflow went from empty to non-empty")
+	}
+	if v, ok := s.Pop(); !ok || v != 3 {
+		t.Errorf("Pop() = %d, %v, want 3, true", v, ok)
+	}
+}
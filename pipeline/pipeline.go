@@ -0,0 +1,231 @@
+// Package pipeline connects goroutine stages with grin SPSC rings.
+//
+// A pipeline starts at one or more Sources and is extended with Map, MapBatch,
+// Sink and SinkBatch. Every stage runs on its own goroutine, and every Stream
+// between two stages is a grin ring with exactly one producer and one consumer.
+//
+//	p := pipeline.New(ctx)
+//	lines := pipeline.Source(p, "read", readLines)
+//	events := pipeline.Map(lines, "parse", parse)
+//	pipeline.SinkBatch(events, "write", 256, write)
+//	err := p.Wait()
+//
+// The first stage error, or the parent context being cancelled, aborts the whole
+// pipeline. Stop instead ends the sources and lets every item already in the
+// pipeline drain through the remaining stages.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/andrewwormald/grin"
+)
+
+// Option configures a Pipeline created by New.
+type Option func(*options)
+
+type options struct {
+	ringSize int
+	wait     grin.WaitStrategy
+	onError  func(stage string, err error) error
+}
+
+func defaultOptions() options {
+	return options{
+		ringSize: 1024,
+	}
+}
+
+// WithRingSize sets the size of the ring between each pair of stages. It must be
+// a power of two. The default is 1024.
+func WithRingSize(n int) Option {
+	return func(o *options) {
+		o.ringSize = n
+	}
+}
+
+// WithWaitStrategy makes every stage wait on w when its input is empty or its
+// output is full. By default each ring gets its own grin.NewParkWait().
+func WithWaitStrategy(w grin.WaitStrategy) Option {
+	return func(o *options) {
+		o.wait = w
+	}
+}
+
+// WithErrorHandler is called with every error returned by a stage function.
+// Returning nil skips the item and carries on, returning an error aborts the
+// pipeline with it. By default every error aborts the pipeline.
+func WithErrorHandler(fn func(stage string, err error) error) Option {
+	return func(o *options) {
+		o.onError = fn
+	}
+}
+
+// Pipeline runs a graph of stages connected by grin rings.
+type Pipeline struct {
+	opts options
+
+	ctx     context.Context // Cancelled to abort every stage
+	cancel  context.CancelFunc
+	aborted atomic.Bool
+
+	stop     context.Context // Cancelled to end the sources only
+	stopFunc context.CancelFunc
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+	stages  []*stage
+	streams []streamInfo
+}
+
+type streamInfo struct {
+	producer string
+	consumed bool
+}
+
+// New creates an empty pipeline whose stages stop when ctx is cancelled.
+func New(ctx context.Context, opts ...Option) *Pipeline {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := &Pipeline{opts: o}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.stop, p.stopFunc = context.WithCancel(p.ctx)
+	return p
+}
+
+// Stop ends every Source and lets the items already in the pipeline drain through
+// the remaining stages. Call Wait to wait for the drain to finish.
+func (p *Pipeline) Stop() {
+	p.stopFunc()
+}
+
+// Wait waits for every stage to finish and returns the error that aborted the
+// pipeline, if any. It panics if a Stream has no downstream stage, as the
+// pipeline could then never drain.
+func (p *Pipeline) Wait() error {
+	p.mu.Lock()
+	for _, s := range p.streams {
+		if !s.consumed {
+			p.mu.Unlock()
+			panic(fmt.Sprintf("pipeline: output of stage %q is never consumed", s.producer))
+		}
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	p.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// StageMetrics is a snapshot of the counters of one stage.
+type StageMetrics struct {
+	Name string
+
+	In      uint64 // Items taken from the input ring
+	Out     uint64 // Items pushed to the output ring
+	Errors  uint64 // Errors returned by the stage function
+	Backlog int    // Items waiting in the input ring
+}
+
+// Metrics returns a snapshot of every stage, in the order they were added.
+// It is safe to call from any goroutine.
+func (p *Pipeline) Metrics() []StageMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	metrics := make([]StageMetrics, 0, len(p.stages))
+	for _, s := range p.stages {
+		m := StageMetrics{
+			Name:   s.name,
+			In:     s.in.Load(),
+			Out:    s.out.Load(),
+			Errors: s.errors.Load(),
+		}
+		if s.backlog != nil {
+			m.Backlog = s.backlog()
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+type stage struct {
+	name    string
+	backlog func() int
+
+	in     atomic.Uint64
+	out    atomic.Uint64
+	errors atomic.Uint64
+}
+
+// addStage registers a stage consuming from the stream produced by stage input,
+// which is -1 for sources.
+func (p *Pipeline) addStage(name string, input int, backlog func() int) *stage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if input >= 0 {
+		if p.streams[input].consumed {
+			panic("pipeline: stream already has a downstream stage")
+		}
+		p.streams[input].consumed = true
+	}
+
+	s := &stage{name: name, backlog: backlog}
+	p.stages = append(p.stages, s)
+	return s
+}
+
+// addStream registers the output of a stage and returns its index.
+func (p *Pipeline) addStream(producer string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.streams = append(p.streams, streamInfo{producer: producer})
+	return len(p.streams) - 1
+}
+
+// run runs fn on its own goroutine, aborting the pipeline if it fails.
+func (p *Pipeline) run(fn func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := fn(); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// handle records an error returned by the function of s, and returns it if it
+// should abort the pipeline.
+func (p *Pipeline) handle(s *stage, err error) error {
+	s.errors.Add(1)
+	if p.opts.onError != nil {
+		err = p.opts.onError(s.name, err)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("pipeline: stage %q: %w", s.name, err)
+}
+
+func (p *Pipeline) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+
+	p.aborted.Store(true)
+	p.cancel()
+}
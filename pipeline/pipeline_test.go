@@ -0,0 +1,254 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin/pipeline"
+)
+
+// count emits 0 to n-1.
+func count(n int) func(context.Context, func(int) error) error {
+	return func(ctx context.Context, emit func(int) error) error {
+		for i := 0; i < n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func wait(t *testing.T, p *pipeline.Pipeline) error {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out - pipeline did not finish")
+		return nil
+	}
+}
+
+func TestPipelineOrder(t *testing.T) {
+	const n = 100000
+	p := pipeline.New(context.Background(), pipeline.WithRingSize(64))
+
+	nums := pipeline.Source(p, "count", count(n))
+	strs := pipeline.Map(nums, "format", func(i int) (string, error) {
+		return strconv.Itoa(i), nil
+	})
+	back := pipeline.MapBatch(strs, "parse", 16, func(batch []string) ([]int, error) {
+		out := make([]int, len(batch))
+		for i, s := range batch {
+			out[i], _ = strconv.Atoi(s)
+		}
+		return out, nil
+	})
+
+	next := 0
+	pipeline.Sink(back, "check", func(i int) error {
+		if i != next {
+			t.Errorf("Got %d, want %d", i, next)
+		}
+		next++
+		return nil
+	})
+
+	if err := wait(t, p); err != nil {
+		t.Fatalf("Wait() error = %v, want nil", err)
+	}
+	if next != n {
+		t.Errorf("Sink saw %d items, want %d", next, n)
+	}
+
+	for _, m := range p.Metrics() {
+		if m.Name != "count" && m.In != n {
+			t.Errorf("Stage %q In = %d, want %d", m.Name, m.In, n)
+		}
+		if m.Name != "check" && m.Out != n {
+			t.Errorf("Stage %q Out = %d, want %d", m.Name, m.Out, n)
+		}
+		if m.Backlog != 0 {
+			t.Errorf("Stage %q Backlog = %d, want 0", m.Name, m.Backlog)
+		}
+	}
+}
+
+func TestPipelineSinkBatch(t *testing.T) {
+	p := pipeline.New(context.Background(), pipeline.WithRingSize(16))
+
+	var total, batches int
+	pipeline.SinkBatch(pipeline.Source(p, "count", count(1000)), "sum", 8, func(batch []int) error {
+		if len(batch) == 0 || len(batch) > 8 {
+			t.Errorf("Batch length = %d, want 1 to 8", len(batch))
+		}
+		for _, i := range batch {
+			total += i
+		}
+		batches++
+		return nil
+	})
+
+	if err := wait(t, p); err != nil {
+		t.Fatalf("Wait() error = %v, want nil", err)
+	}
+	if want := 999 * 1000 / 2; total != want {
+		t.Errorf("Sum = %d, want %d", total, want)
+	}
+	if batches < 1000/8 {
+		t.Errorf("Batches = %d, want at least %d", batches, 1000/8)
+	}
+}
+
+func TestPipelineErrorAborts(t *testing.T) {
+	boom := errors.New("boom")
+	p := pipeline.New(context.Background(), pipeline.WithRingSize(8))
+
+	// Never ends by itself, so the error must cancel it
+	forever := pipeline.Source(p, "forever", func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	})
+	failed := pipeline.Map(forever, "fail", func(i int) (int, error) {
+		if i == 100 {
+			return 0, boom
+		}
+		return i, nil
+	})
+	pipeline.Sink(failed, "discard", func(int) error { return nil })
+
+	err := wait(t, p)
+	if !errors.Is(err, boom) {
+		t.Errorf("Wait() error = %v, want %v", err, boom)
+	}
+
+	for _, m := range p.Metrics() {
+		if m.Name == "fail" && m.Errors != 1 {
+			t.Errorf("Stage %q Errors = %d, want 1", m.Name, m.Errors)
+		}
+	}
+}
+
+func TestPipelineErrorHandler(t *testing.T) {
+	bad := errors.New("odd")
+	p := pipeline.New(context.Background(), pipeline.WithErrorHandler(func(stage string, err error) error {
+		if stage != "even" {
+			t.Errorf("Handler stage = %q, want %q", stage, "even")
+		}
+		return nil
+	}))
+
+	evens := pipeline.Map(pipeline.Source(p, "count", count(10)), "even", func(i int) (int, error) {
+		if i%2 != 0 {
+			return 0, bad
+		}
+		return i, nil
+	})
+
+	var got []int
+	pipeline.Sink(evens, "collect", func(i int) error {
+		got = append(got, i)
+		return nil
+	})
+
+	if err := wait(t, p); err != nil {
+		t.Fatalf("Wait() error = %v, want nil", err)
+	}
+	if len(got) != 5 {
+		t.Errorf("Got %v, want 5 even numbers", got)
+	}
+	if m := p.Metrics()[1]; m.Errors != 5 || m.Out != 5 {
+		t.Errorf("Stage %q Errors = %d, Out = %d, want 5 and 5", m.Name, m.Errors, m.Out)
+	}
+}
+
+func TestPipelineContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := pipeline.New(ctx)
+
+	// Blocks the sink so the source fills its ring
+	pipeline.Sink(pipeline.Source(p, "count", count(1<<30)), "block", func(int) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	time.Sleep(time.Millisecond)
+	cancel()
+
+	if err := wait(t, p); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPipelineStopDrains(t *testing.T) {
+	p := pipeline.New(context.Background(), pipeline.WithRingSize(64))
+
+	var emitted atomic.Int64
+	nums := pipeline.Source(p, "forever", func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+			emitted.Add(1)
+		}
+	})
+	slow := pipeline.Map(nums, "slow", func(i int) (int, error) {
+		time.Sleep(time.Microsecond)
+		return i, nil
+	})
+
+	var seen int64
+	pipeline.Sink(slow, "count", func(int) error {
+		seen++
+		return nil
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+
+	if err := wait(t, p); err != nil {
+		t.Fatalf("Wait() error = %v, want nil", err)
+	}
+	if seen != emitted.Load() {
+		t.Errorf("Sink saw %d items, want all %d emitted", seen, emitted.Load())
+	}
+}
+
+func TestPipelineUnconsumedStreamPanics(t *testing.T) {
+	p := pipeline.New(context.Background())
+	pipeline.Source(p, "dangling", count(1))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Wait() did not panic with an unconsumed stream")
+		}
+	}()
+	p.Wait()
+}
+
+func TestPipelineStreamConsumedTwicePanics(t *testing.T) {
+	p := pipeline.New(context.Background())
+	nums := pipeline.Source(p, "count", count(1))
+	pipeline.Sink(nums, "first", func(int) error { return nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Sink() did not panic on a stream that already has a consumer")
+		}
+	}()
+	pipeline.Sink(nums, "second", func(int) error { return nil })
+}
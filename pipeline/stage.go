@@ -0,0 +1,250 @@
+package pipeline
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/andrewwormald/grin"
+)
+
+// Stream is the ring between two stages. It is pushed to by exactly one stage and
+// must be consumed by exactly one stage.
+type Stream[T any] struct {
+	p     *Pipeline
+	index int
+	ring  grin.RingBuffer[T]
+	wait  grin.WaitStrategy
+
+	closed   atomic.Bool // Set by the producer once it will never push again
+	readable func() bool
+	writable func() bool
+}
+
+func newStream[T any](p *Pipeline, producer string) *Stream[T] {
+	w := p.opts.wait
+	if w == nil {
+		w = grin.NewParkWait()
+	}
+
+	s := &Stream[T]{
+		p:     p,
+		index: p.addStream(producer),
+		ring:  grin.New[T](p.opts.ringSize, grin.WithWaitStrategy(w)),
+		wait:  w,
+	}
+	// Built once so that waiting doesn't allocate
+	s.readable = func() bool { return s.ring.Len() > 0 || s.closed.Load() }
+	s.writable = func() bool { return s.ring.Available() > 0 }
+	return s
+}
+
+// push blocks until t is in the ring or ctx is done.
+func (s *Stream[T]) push(ctx context.Context, t T) error {
+	for !s.ring.Push(t) {
+		if err := s.wait.Wait(ctx, s.writable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close tells the consumer that nothing more will be pushed.
+func (s *Stream[T]) close() {
+	s.ring.Flush()
+	s.closed.Store(true)
+	s.wait.Notify()
+}
+
+// next blocks until an item is available, returning false once the stream is
+// closed and drained.
+func (s *Stream[T]) next(ctx context.Context) (T, bool, error) {
+	for {
+		if t, ok := s.ring.Pop(); ok {
+			return t, true, nil
+		}
+		if s.closed.Load() {
+			// Everything pushed before close is visible now
+			t, ok := s.ring.Pop()
+			return t, ok, nil
+		}
+		if err := s.wait.Wait(ctx, s.readable); err != nil {
+			var zero T
+			return zero, false, err
+		}
+	}
+}
+
+// nextBatch blocks until at least one item is available and appends up to max
+// items to batch[:0]. It returns an empty batch once the stream is closed and
+// drained.
+func (s *Stream[T]) nextBatch(ctx context.Context, batch []T, max int) ([]T, error) {
+	batch = batch[:0]
+	t, ok, err := s.next(ctx)
+	if !ok {
+		return batch, err
+	}
+
+	batch = append(batch, t)
+	for len(batch) < max {
+		t, ok := s.ring.Pop()
+		if !ok {
+			break
+		}
+		batch = append(batch, t)
+	}
+	return batch, nil
+}
+
+// Source adds a stage that produces items by calling emit, which blocks while the
+// output ring is full. emit must only be called from fn's goroutine. The stage
+// ends when fn returns. The ctx passed to fn is
+// cancelled by Stop, which ends the source without aborting the pipeline.
+func Source[T any](p *Pipeline, name string, fn func(ctx context.Context, emit func(T) error) error) *Stream[T] {
+	st := p.addStage(name, -1, nil)
+	out := newStream[T](p, name)
+
+	emit := func(t T) error {
+		if err := out.push(p.stop, t); err != nil {
+			return err
+		}
+		st.out.Add(1)
+		return nil
+	}
+
+	p.run(func() error {
+		defer out.close()
+
+		err := fn(p.stop, emit)
+		if err != nil && p.stop.Err() != nil && p.ctx.Err() == nil {
+			// Stopped, let the rest of the pipeline drain
+			return nil
+		}
+		if err != nil && p.ctx.Err() == nil {
+			return p.handle(st, err)
+		}
+		return err
+	})
+	return out
+}
+
+// Map adds a stage that calls fn for every item of in and pushes the result.
+func Map[In, Out any](in *Stream[In], name string, fn func(In) (Out, error)) *Stream[Out] {
+	p := in.p
+	st := p.addStage(name, in.index, in.ring.Len)
+	out := newStream[Out](p, name)
+
+	p.run(func() error {
+		defer out.close()
+
+		for !p.aborted.Load() {
+			t, ok, err := in.next(p.ctx)
+			if !ok {
+				return err
+			}
+			st.in.Add(1)
+
+			r, err := fn(t)
+			if err != nil {
+				if err := p.handle(st, err); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := out.push(p.ctx, r); err != nil {
+				return err
+			}
+			st.out.Add(1)
+		}
+		return nil
+	})
+	return out
+}
+
+// MapBatch adds a stage that calls fn with up to size items of in at a time and
+// pushes every result. fn must not keep the batch, it is reused.
+func MapBatch[In, Out any](in *Stream[In], name string, size int, fn func([]In) ([]Out, error)) *Stream[Out] {
+	p := in.p
+	st := p.addStage(name, in.index, in.ring.Len)
+	out := newStream[Out](p, name)
+
+	p.run(func() error {
+		defer out.close()
+
+		batch := make([]In, 0, size)
+		for !p.aborted.Load() {
+			var err error
+			batch, err = in.nextBatch(p.ctx, batch, size)
+			if len(batch) == 0 {
+				return err
+			}
+			st.in.Add(uint64(len(batch)))
+
+			rs, err := fn(batch)
+			if err != nil {
+				if err := p.handle(st, err); err != nil {
+					return err
+				}
+				continue
+			}
+
+			for _, r := range rs {
+				if err := out.push(p.ctx, r); err != nil {
+					return err
+				}
+			}
+			st.out.Add(uint64(len(rs)))
+		}
+		return nil
+	})
+	return out
+}
+
+// Sink adds a final stage that calls fn for every item of in.
+func Sink[T any](in *Stream[T], name string, fn func(T) error) {
+	p := in.p
+	st := p.addStage(name, in.index, in.ring.Len)
+
+	p.run(func() error {
+		for !p.aborted.Load() {
+			t, ok, err := in.next(p.ctx)
+			if !ok {
+				return err
+			}
+			st.in.Add(1)
+
+			if err := fn(t); err != nil {
+				if err := p.handle(st, err); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SinkBatch adds a final stage that calls fn with up to size items of in at a
+// time. fn must not keep the batch, it is reused.
+func SinkBatch[T any](in *Stream[T], name string, size int, fn func([]T) error) {
+	p := in.p
+	st := p.addStage(name, in.index, in.ring.Len)
+
+	p.run(func() error {
+		batch := make([]T, 0, size)
+		for !p.aborted.Load() {
+			var err error
+			batch, err = in.nextBatch(p.ctx, batch, size)
+			if len(batch) == 0 {
+				return err
+			}
+			st.in.Add(uint64(len(batch)))
+
+			if err := fn(batch); err != nil {
+				if err := p.handle(st, err); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,213 @@
+package grin
+
+import "sync/atomic"
+
+// Scheduling decides which lane of a PriorityRing the consumer pops from next.
+type Scheduling int
+
+const (
+	// StrictPriority always pops from the lowest numbered non-empty lane. Use
+	// WithStarvationLimit to make sure lower priority lanes still make progress.
+	StrictPriority Scheduling = iota
+
+	// WeightedRoundRobin pops up to Weight items from each lane in turn.
+	WeightedRoundRobin
+)
+
+// LaneConfig configures one lane of a PriorityRing.
+type LaneConfig struct {
+	// Size of the lane, which must be a power of 2
+	Size int
+
+	// Weight is the number of items popped from the lane per turn with
+	// WeightedRoundRobin, at least 1. Ignored with StrictPriority.
+	Weight int
+}
+
+// LaneStats are the counters of a Lane.
+type LaneStats struct {
+	Pushed   uint64
+	Rejected uint64
+	Popped   uint64
+	Len      int
+}
+
+// Lane is one SPSC lane of a PriorityRing, with its own producer.
+type Lane[T any] struct {
+	ring   RingBuffer[T]
+	weight int
+	_      [cacheLineSize]byte // Do not remove
+
+	rejected atomic.Uint64           // Owned by the producer
+	_        [cacheLineSize - 8]byte // Do not remove
+
+	waiting int                 // Owned by the consumer, pops served by higher lanes while this one waited
+	_       [cacheLineSize]byte // Do not remove
+}
+
+// PriorityRing is made of several SPSC lanes, each with its own producer, drained
+// by a single consumer that picks a lane on every Pop according to its Scheduling.
+// Lane 0 has the highest priority.
+type PriorityRing[T any] struct {
+	lanes           []*Lane[T]
+	sched           Scheduling
+	starvationLimit int
+
+	current int // Owned by the consumer, lane being served by WeightedRoundRobin
+	credit  int // Owned by the consumer, pops left in the current lane's turn
+}
+
+// NewPriorityRing creates a new priority ring with one lane per config. Panics if
+// no lanes are given, or if any lane has an invalid size or weight.
+func NewPriorityRing[T any](sched Scheduling, lanes []LaneConfig, opts ...Option) *PriorityRing[T] {
+	if len(lanes) == 0 {
+		panic("priority ring needs at least one lane")
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := &PriorityRing[T]{
+		sched:           sched,
+		starvationLimit: o.starvationLimit,
+	}
+
+	for _, cfg := range lanes {
+		if sched == WeightedRoundRobin && cfg.Weight < 1 {
+			panic("lane weight must be at least 1")
+		}
+
+		p.lanes = append(p.lanes, &Lane[T]{
+			ring:   New[T](cfg.Size, opts...),
+			weight: cfg.Weight,
+		})
+	}
+
+	p.credit = p.lanes[0].weight
+	return p
+}
+
+// Lane returns lane i, where lane 0 has the highest priority.
+func (p *PriorityRing[T]) Lane(i int) *Lane[T] {
+	return p.lanes[i]
+}
+
+// Pop removes and returns an item from the lane picked by the ring's Scheduling.
+// Returns (zero value, false) if every lane is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (p *PriorityRing[T]) Pop() (T, bool) {
+	if p.sched == WeightedRoundRobin {
+		return p.popWeighted()
+	}
+	return p.popStrict()
+}
+
+func (p *PriorityRing[T]) popStrict() (T, bool) {
+	if p.starvationLimit > 0 {
+		// Serve a starved lane ahead of its turn
+		for i := 1; i < len(p.lanes); i++ {
+			if l := p.lanes[i]; l.waiting >= p.starvationLimit {
+				if val, ok := l.ring.Pop(); ok {
+					p.served(i)
+					return val, true
+				}
+				l.waiting = 0
+			}
+		}
+	}
+
+	for i, l := range p.lanes {
+		if val, ok := l.ring.Pop(); ok {
+			p.served(i)
+			return val, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// served updates starvation tracking after popping from lane i.
+func (p *PriorityRing[T]) served(i int) {
+	if p.starvationLimit == 0 {
+		return
+	}
+
+	p.lanes[i].waiting = 0
+	for _, l := range p.lanes[i+1:] {
+		if l.ring.Len() > 0 {
+			l.waiting++
+		}
+	}
+}
+
+func (p *PriorityRing[T]) popWeighted() (T, bool) {
+	for range len(p.lanes) + 1 {
+		l := p.lanes[p.current]
+		if p.credit > 0 {
+			if val, ok := l.ring.Pop(); ok {
+				p.credit--
+				return val, true
+			}
+		}
+
+		p.current = (p.current + 1) % len(p.lanes)
+		p.credit = p.lanes[p.current].weight
+	}
+
+	var zero T
+	return zero, false
+}
+
+// Len returns the number of items across all lanes.
+func (p *PriorityRing[T]) Len() int {
+	var n int
+	for _, l := range p.lanes {
+		n += l.ring.Len()
+	}
+	return n
+}
+
+// Push adds an item to the lane.
+// Returns false if the lane is full (non-blocking).
+//
+// Only safe to call from the lane's single producer goroutine.
+func (l *Lane[T]) Push(t T) bool {
+	if !l.ring.Push(t) {
+		l.rejected.Store(l.rejected.Load() + 1)
+		return false
+	}
+	return true
+}
+
+// Flush publishes any pending pushes to the consumer.
+// It is a no-op unless the ring was created with WithPublishEvery.
+//
+// Only safe to call from the lane's single producer goroutine.
+func (l *Lane[T]) Flush() {
+	l.ring.Flush()
+}
+
+func (l *Lane[T]) Cap() int {
+	return l.ring.Cap()
+}
+
+func (l *Lane[T]) Len() int {
+	return l.ring.Len()
+}
+
+// Stats returns the lane's counters. Pushed and Popped are the ring's published
+// tail and head, so Popped never exceeds Pushed. It is safe to call from any
+// goroutine.
+func (l *Lane[T]) Stats() LaneStats {
+	seq := l.ring.Stats()
+	return LaneStats{
+		Pushed:   seq.Tail,
+		Rejected: l.rejected.Load(),
+		Popped:   seq.Head,
+		Len:      int(seq.Tail - seq.Head),
+	}
+}
@@ -0,0 +1,160 @@
+package grin_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestPriorityRingStrict(t *testing.T) {
+	p := grin.NewPriorityRing[string](grin.StrictPriority, []grin.LaneConfig{{Size: 4}, {Size: 4}})
+	control, bulk := p.Lane(0), p.Lane(1)
+
+	bulk.Push("bulk-1")
+	bulk.Push("bulk-2")
+	control.Push("control-1")
+
+	for _, want := range []string{"control-1", "bulk-1", "bulk-2"} {
+		if got, ok := p.Pop(); !ok || got != want {
+			t.Errorf("Pop() = (%q, %v), want (%q, true)", got, ok, want)
+		}
+	}
+	if got, ok := p.Pop(); ok {
+		t.Errorf("Pop() on empty ring = (%q, %v), want (\"\", false)", got, ok)
+	}
+}
+
+func TestPriorityRingStarvationLimit(t *testing.T) {
+	p := grin.NewPriorityRing[int](grin.StrictPriority,
+		[]grin.LaneConfig{{Size: 16}, {Size: 16}}, grin.WithStarvationLimit(3))
+
+	for i := 0; i < 8; i++ {
+		p.Lane(0).Push(i)
+	}
+	p.Lane(1).Push(100)
+
+	var got []int
+	for i := 0; i < 5; i++ {
+		val, _ := p.Pop()
+		got = append(got, val)
+	}
+
+	want := []int{0, 1, 2, 100, 3}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Pop() order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPriorityRingWeighted(t *testing.T) {
+	p := grin.NewPriorityRing[int](grin.WeightedRoundRobin,
+		[]grin.LaneConfig{{Size: 16, Weight: 3}, {Size: 16, Weight: 1}})
+
+	for i := 0; i < 6; i++ {
+		p.Lane(0).Push(i)
+		p.Lane(1).Push(100 + i)
+	}
+
+	want := []int{0, 1, 2, 100, 3, 4, 5, 101, 102, 103, 104, 105}
+	for _, w := range want {
+		if got, ok := p.Pop(); !ok || got != w {
+			t.Fatalf("Pop() = (%d, %v), want (%d, true)", got, ok, w)
+		}
+	}
+}
+
+func TestPriorityRingStats(t *testing.T) {
+	p := grin.NewPriorityRing[int](grin.StrictPriority, []grin.LaneConfig{{Size: 2}, {Size: 8}})
+	lane := p.Lane(0)
+
+	lane.Push(1)
+	lane.Push(2)
+	lane.Push(3)
+	p.Pop()
+
+	want := grin.LaneStats{Pushed: 2, Rejected: 1, Popped: 1, Len: 1}
+	if got := lane.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if lane.Cap() != 2 || p.Lane(1).Cap() != 8 {
+		t.Errorf("Cap() = (%d, %d), want (2, 8)", lane.Cap(), p.Lane(1).Cap())
+	}
+}
+
+func TestPriorityRingStatsPopBeforePushReturns(t *testing.T) {
+	hook := &notifyHook{}
+	p := grin.NewPriorityRing[int](grin.StrictPriority, []grin.LaneConfig{{Size: 2}}, grin.WithWaitStrategy(hook))
+	lane := p.Lane(0)
+
+	for i := 0; i < 4; i++ {
+		// Pop as soon as the push is published, before Push returns
+		hook.fn = func() {
+			if _, ok := p.Pop(); !ok {
+				t.Fatal("Pop() = false after the push was published")
+			}
+			if s := lane.Stats(); s.Popped > s.Pushed || s.Len < 0 {
+				t.Errorf("Stats() = %+v after popping an item, want Popped <= Pushed", s)
+			}
+		}
+		lane.Push(i)
+	}
+}
+
+func TestPriorityRingInvalidWeight(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("NewPriorityRing should panic for a zero weight with WeightedRoundRobin")
+		}
+	}()
+
+	grin.NewPriorityRing[int](grin.WeightedRoundRobin, []grin.LaneConfig{{Size: 8}})
+}
+
+func TestConcurrentPriorityRing(t *testing.T) {
+	const numLanes = 3
+	const numItems = 20000
+
+	p := grin.NewPriorityRing[int](grin.WeightedRoundRobin, []grin.LaneConfig{
+		{Size: 64, Weight: 4}, {Size: 64, Weight: 2}, {Size: 64, Weight: 1},
+	})
+
+	var wg sync.WaitGroup
+	for lane := 0; lane < numLanes; lane++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numItems; i++ {
+				for !p.Lane(lane).Push(lane*numItems + i) {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	next := make([]int, numLanes)
+	deadline := time.Now().Add(10 * time.Second)
+	for received := 0; received < numLanes*numItems; {
+		if time.Now().After(deadline) {
+			t.Fatal("Test timed out - possible deadlock")
+		}
+
+		val, ok := p.Pop()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+
+		lane, i := val/numItems, val%numItems
+		if i != next[lane] {
+			t.Fatalf("lane %d: got %d, want %d", lane, i, next[lane])
+		}
+		next[lane]++
+		received++
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,120 @@
+package grin
+
+import (
+	"runtime"
+	"testing"
+)
+
+// once runs fn the first time the returned hook is called.
+func once(fn func()) func() {
+	done := false
+	return func() {
+		if !done {
+			done = true
+			fn()
+		}
+	}
+}
+
+// The producer publishes between the consumer finding the buffer empty and it
+// publishing its lagging head, so the producer still sees the old head and does not
+// signal Readable.
+func TestReadableBatchedPublishRace(t *testing.T) {
+	b := New[int](4, WithPublishEvery(2)).(*ringBuffer[int])
+	readable := b.Readable()
+
+	b.Push(1)
+	b.Flush()
+	<-readable
+	if v, ok := b.Pop(); !ok || v != 1 {
+		t.Fatalf("Pop() = %d, %v, want 1, true", v, ok)
+	}
+
+	testHookPopEmpty = once(func() {
+		b.Push(2)
+		b.Flush()
+	})
+	defer func() { testHookPopEmpty = nil }()
+
+	if v, ok := b.Pop(); ok {
+		if v != 2 {
+			t.Fatalf("Pop() = %d, want 2", v)
+		}
+		return
+	}
+
+	select {
+	case <-readable:
+	default:
+		t.Fatal("Pop() found the buffer empty and Readable() did not fire, the consumer would wait forever")
+	}
+}
+
+// The consumer publishes its pops between the producer finding the buffer full and
+// it flushing its lagging tail, so the consumer still sees the old tail and does not
+// signal Writable.
+func TestWritableBatchedPublishRace(t *testing.T) {
+	b := New[int](4, WithPublishEvery(3)).(*ringBuffer[int])
+	writable := b.Writable()
+
+	for i := 0; i < 4; i++ {
+		if !b.Push(i) {
+			t.Fatalf("Push(%d) = false, want true", i)
+		}
+	}
+
+	testHookPushFull = once(func() {
+		for i := 0; i < 3; i++ {
+			if v, ok := b.Pop(); !ok || v != i {
+				t.Fatalf("Pop() = %d, %v, want %d, true", v, ok, i)
+			}
+		}
+	})
+	defer func() { testHookPushFull = nil }()
+
+	if b.Push(4) {
+		return
+	}
+
+	select {
+	case <-writable:
+	default:
+		t.Fatal("Push() found the buffer full and Writable() did not fire, the producer would wait forever")
+	}
+}
+
+// The consumer seeks back to the oldest item while the producer is deciding
+// whether to overwrite it. The producer must back off and Seek must succeed.
+func TestLogSeekDuringOverwrite(t *testing.T) {
+	l := NewLog[int](2)
+	l.Push(0)
+	l.Push(1)
+	l.Pop()
+	l.Pop()
+
+	seeked := make(chan error, 1)
+	testHookLogOverwrite = once(func() {
+		go func() {
+			seeked <- l.Seek(0)
+		}()
+
+		// Let the consumer publish its position before the producer checks it
+		for l.read.Load() != 0 && len(seeked) == 0 {
+			runtime.Gosched()
+		}
+	})
+	defer func() { testHookLogOverwrite = nil }()
+
+	if l.Push(2) {
+		t.Fatal("Push() overwrote the item the consumer seeked back to")
+	}
+	if err := <-seeked; err != nil {
+		t.Fatalf("Seek(0) error = %v, want nil as the item was retained", err)
+	}
+	if oldest := l.Oldest(); oldest != 0 {
+		t.Errorf("Oldest() = %d, want 0", oldest)
+	}
+	if v, seq, ok := l.PopSeq(); !ok || seq != 0 || v != 0 {
+		t.Errorf("PopSeq() = (%d, %d, %v), want (0, 0, true)", v, seq, ok)
+	}
+}
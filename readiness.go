@@ -0,0 +1,55 @@
+package grin
+
+import "sync/atomic"
+
+// readiness holds the edge triggered channels returned by Readable and Writable.
+// Rings only check for transitions once a channel has been asked for.
+type readiness struct {
+	readable      chan struct{}
+	writable      chan struct{}
+	eventFD       *EventFD
+	watchReadable atomic.Bool
+	watchWritable atomic.Bool
+}
+
+func newReadiness() *readiness {
+	return &readiness{
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+	}
+}
+
+// Readable starts watching for empty to non-empty transitions.
+func (r *readiness) Readable() <-chan struct{} {
+	r.watchReadable.Store(true)
+	return r.readable
+}
+
+// Writable starts watching for full to non-full transitions.
+func (r *readiness) Writable() <-chan struct{} {
+	r.watchWritable.Store(true)
+	return r.writable
+}
+
+// becameReadable is called by the producer on an empty to non-empty transition.
+func (r *readiness) becameReadable() {
+	signal(r.readable)
+	if r.eventFD != nil {
+		r.eventFD.signal()
+	}
+}
+
+// signal wakes a reader of ch, unless it has already been signalled.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// closedChan is returned by Writable for rings whose Push never fails.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
@@ -0,0 +1,142 @@
+package grin_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func readinessRings(t *testing.T) map[string]grin.RingBuffer[int] {
+	memory, err := grin.NewSpill[int](4, grin.FullSpillMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]grin.RingBuffer[int]{
+		"ring":     grin.New[int](4),
+		"batched":  grin.New[int](4, grin.WithPublishEvery(2)),
+		"local":    grin.NewLocal[int](4),
+		"growable": grin.NewGrowable[int](2, 4),
+		"spill":    memory,
+	}
+}
+
+func TestReadableEdgeTriggered(t *testing.T) {
+	for name, buf := range readinessRings(t) {
+		t.Run(name, func(t *testing.T) {
+			readable := buf.Readable()
+
+			buf.Push(1)
+			buf.Flush()
+			select {
+			case <-readable:
+			default:
+				t.Fatal("Readable() did not fire on empty to non-empty")
+			}
+
+			buf.Push(2)
+			buf.Flush()
+			select {
+			case <-readable:
+				t.Fatal("Readable() fired without an empty to non-empty transition")
+			default:
+			}
+		})
+	}
+}
+
+func TestWritableEdgeTriggered(t *testing.T) {
+	rings := map[string]grin.RingBuffer[int]{
+		"ring":  grin.New[int](4),
+		"local": grin.NewLocal[int](4),
+	}
+
+	for name, buf := range rings {
+		t.Run(name, func(t *testing.T) {
+			writable := buf.Writable()
+
+			for i := 0; i < 4; i++ {
+				buf.Push(i)
+			}
+			buf.Pop()
+			select {
+			case <-writable:
+			default:
+				t.Fatal("Writable() did not fire on full to non-full")
+			}
+
+			buf.Pop()
+			select {
+			case <-writable:
+				t.Fatal("Writable() fired without a full to non-full transition")
+			default:
+			}
+		})
+	}
+}
+
+func TestWritableNeverFull(t *testing.T) {
+	buf, err := grin.NewSpill[int](4, grin.FullDropOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-buf.Writable():
+	default:
+		t.Error("Writable() is not ready for a ring whose Push never fails")
+	}
+}
+
+func TestConcurrentReadiness(t *testing.T) {
+	for name, buf := range map[string]grin.RingBuffer[int]{
+		"ring":     grin.New[int](8),
+		"batched":  grin.New[int](8, grin.WithPublishEvery(4)),
+		"growable": grin.NewGrowable[int](2, 8),
+	} {
+		t.Run(name, func(t *testing.T) {
+			const numItems = 20000
+			done := make(chan bool, 2)
+
+			go func() {
+				writable := buf.Writable()
+				for i := 0; i < numItems; i++ {
+					for !buf.Push(i) {
+						<-writable
+					}
+					if i%100 == 0 {
+						buf.Flush()
+					}
+				}
+				buf.Flush()
+				done <- true
+			}()
+
+			go func() {
+				readable := buf.Readable()
+				for i := 0; i < numItems; i++ {
+					for {
+						if val, ok := buf.Pop(); ok {
+							if val != i {
+								t.Errorf("got %d, want %d", val, i)
+							}
+							break
+						}
+						<-readable
+					}
+				}
+				done <- true
+			}()
+
+			timeout := time.After(10 * time.Second)
+			for i := 0; i < 2; i++ {
+				select {
+				case <-done:
+				case <-timeout:
+					t.Fatal("Test timed out - missed readiness transition")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package grin
+
+import "sync/atomic"
+
+// segment is one backing ring in a chain of rings. next is set by the producer once
+// it has stopped pushing to ring, the consumer follows it once ring is drained.
+type segment[T any] struct {
+	ring *ringBuffer[T]
+	next atomic.Pointer[segment[T]]
+}
+
+func newSegment[T any](size int, opts []Option) *segment[T] {
+	return &segment[T]{
+		ring: New[T](size, opts...).(*ringBuffer[T]),
+	}
+}
+
+// link flushes any pending pushes and links next behind s. The producer must not
+// push to s afterwards.
+func (s *segment[T]) link(next *segment[T]) {
+	s.ring.Flush()
+	s.next.Store(next)
+}
+
+// pop removes and returns an item from the segment. If the segment is drained and
+// the producer has moved on, it returns the segment to read from next instead.
+func (s *segment[T]) pop() (T, bool, *segment[T]) {
+	if val, ok := s.ring.Pop(); ok {
+		return val, true, nil
+	}
+
+	next := s.next.Load()
+	if next == nil {
+		var zero T
+		return zero, false, nil
+	}
+
+	// The producer never pushes to a segment after linking the next one, but it
+	// may have pushed to it between our Pop and loading next.
+	if val, ok := s.ring.Pop(); ok {
+		return val, true, nil
+	}
+
+	var zero T
+	return zero, false, next
+}
@@ -0,0 +1,149 @@
+package grin
+
+import (
+	"context"
+	"reflect"
+)
+
+// Selector waits on many rings, and optionally channels, at once, like a select
+// statement. Ring cases are level triggered: a readable ring is one with items to
+// Pop and a writable ring is one with room to Push, and Select does not consume
+// anything from them.
+//
+// Select blocks through the Selector's WaitStrategy, and only polls the rings
+// when notified. The rings must therefore be created with WithWaitStrategy using
+// the same strategy, or a parking strategy will sleep through their changes.
+type Selector struct {
+	wait  WaitStrategy
+	cases []selectCase
+	chans []reflect.SelectCase
+
+	recv   reflect.Value
+	recvOK bool
+
+	next int // Case to poll first, rotated so that no ring starves the others
+}
+
+// selectCase is either a ring case with a ready check, or a channel case.
+type selectCase struct {
+	ready func() bool
+	ch    int // Index into chans, -1 for ring cases
+}
+
+// NewSelector creates a new Selector that blocks using w.
+func NewSelector(w WaitStrategy) *Selector {
+	return &Selector{wait: w}
+}
+
+// Readable adds a case that fires while r has items to Pop and returns its index.
+func (s *Selector) Readable(r interface{ Len() int }) int {
+	return s.add(selectCase{ready: func() bool { return r.Len() > 0 }, ch: -1})
+}
+
+// Writable adds a case that fires while r has room to Push and returns its index.
+func (s *Selector) Writable(r interface{ Available() int }) int {
+	return s.add(selectCase{ready: func() bool { return r.Available() > 0 }, ch: -1})
+}
+
+// Recv adds a case that fires when a value is received from ch and returns its
+// index. Panics if ch is not a channel that can be received from.
+//
+// Each Select with channel cases runs a helper goroutine to receive from them, so
+// unlike ring only selects it allocates.
+func (s *Selector) Recv(ch any) int {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("recv case must be a receivable channel")
+	}
+
+	s.chans = append(s.chans, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: v})
+	return s.add(selectCase{ch: len(s.chans) - 1})
+}
+
+func (s *Selector) add(c selectCase) int {
+	s.cases = append(s.cases, c)
+	return len(s.cases) - 1
+}
+
+// Received returns the value received by the last Select that returned a Recv
+// case, and whether it was sent rather than the zero value of a closed channel.
+func (s *Selector) Received() (any, bool) {
+	if !s.recv.IsValid() {
+		return nil, false
+	}
+	return s.recv.Interface(), s.recvOK
+}
+
+// Select blocks until one of the cases is ready and returns its index, or returns
+// ctx.Err() if ctx is done first. When several cases are ready, ring cases are
+// picked in turn so that none is starved.
+func (s *Selector) Select(ctx context.Context) (int, error) {
+	if len(s.chans) == 0 {
+		fired := -1
+		err := s.wait.Wait(ctx, func() bool {
+			fired = s.poll()
+			return fired >= 0
+		})
+		return fired, err
+	}
+
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Receive from the channels in the background, cancelling the ring wait when
+	// one of them fires.
+	received := make(chan int, 1)
+	go func() {
+		cases := append(s.chans[:len(s.chans):len(s.chans)],
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(waitCtx.Done())})
+
+		chosen, recv, ok := reflect.Select(cases)
+		if chosen == len(s.chans) {
+			received <- -1
+			return
+		}
+
+		s.recv, s.recvOK = recv, ok
+		cancel()
+		received <- chosen
+	}()
+
+	fired := -1
+	err := s.wait.Wait(waitCtx, func() bool {
+		fired = s.poll()
+		return fired >= 0
+	})
+	cancel()
+
+	// The helper may have received a value even if a ring fired, it must not be lost
+	if chosen := <-received; chosen >= 0 {
+		return s.caseOf(chosen), nil
+	}
+	if err != nil {
+		return -1, ctx.Err()
+	}
+	return fired, nil
+}
+
+// poll returns the index of the first ready ring case, or -1 if none are ready.
+func (s *Selector) poll() int {
+	n := len(s.cases)
+	for i := range n {
+		c := (s.next + i) % n
+		if ready := s.cases[c].ready; ready != nil && ready() {
+			s.next = c + 1
+			return c
+		}
+	}
+	return -1
+}
+
+// caseOf returns the case index of channel chosen.
+func (s *Selector) caseOf(chosen int) int {
+	for i, c := range s.cases {
+		if c.ch == chosen {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,156 @@
+package grin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestSelectorReadable(t *testing.T) {
+	w := grin.NewParkWait()
+	a := grin.New[int](8, grin.WithWaitStrategy(w))
+	b := grin.New[int](8, grin.WithWaitStrategy(w))
+
+	sel := grin.NewSelector(w)
+	sel.Readable(a)
+	caseB := sel.Readable(b)
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		b.Push(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := sel.Select(ctx)
+	if err != nil || got != caseB {
+		t.Fatalf("Select() = (%d, %v), want (%d, nil)", got, err, caseB)
+	}
+}
+
+func TestSelectorWritable(t *testing.T) {
+	w := grin.NewParkWait()
+	buf := grin.New[int](2, grin.WithWaitStrategy(w))
+	buf.Push(1)
+	buf.Push(2)
+
+	sel := grin.NewSelector(w)
+	writable := sel.Writable(buf)
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		buf.Pop()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := sel.Select(ctx)
+	if err != nil || got != writable {
+		t.Fatalf("Select() = (%d, %v), want (%d, nil)", got, err, writable)
+	}
+}
+
+func TestSelectorReadableDropOldest(t *testing.T) {
+	w := grin.NewParkWait()
+	buf, err := grin.NewSpill[int](8, grin.FullDropOldest, grin.WithWaitStrategy(w))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sel := grin.NewSelector(w)
+	readable := sel.Readable(buf)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		buf.Push(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := sel.Select(ctx)
+	if err != nil || got != readable {
+		t.Fatalf("Select() = (%d, %v), want (%d, nil)", got, err, readable)
+	}
+}
+
+func TestSelectorRotatesReadyRings(t *testing.T) {
+	a := grin.New[int](8)
+	b := grin.New[int](8)
+	a.Push(1)
+	b.Push(1)
+
+	sel := grin.NewSelector(grin.YieldWait{})
+	caseA := sel.Readable(a)
+	caseB := sel.Readable(b)
+
+	for i, want := range []int{caseA, caseB, caseA, caseB} {
+		got, err := sel.Select(context.Background())
+		if err != nil || got != want {
+			t.Errorf("Select() #%d = (%d, %v), want (%d, nil)", i, got, err, want)
+		}
+	}
+}
+
+func TestSelectorRecv(t *testing.T) {
+	w := grin.NewParkWait()
+	buf := grin.New[int](8, grin.WithWaitStrategy(w))
+	ch := make(chan string, 1)
+
+	sel := grin.NewSelector(w)
+	sel.Readable(buf)
+	recv := sel.Recv(ch)
+
+	ch <- "hello"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := sel.Select(ctx)
+	if err != nil || got != recv {
+		t.Fatalf("Select() = (%d, %v), want (%d, nil)", got, err, recv)
+	}
+	if val, ok := sel.Received(); !ok || val != "hello" {
+		t.Errorf("Received() = (%v, %v), want (hello, true)", val, ok)
+	}
+
+	// A ring becoming readable still fires with channel cases present
+	buf.Push(1)
+	if got, err := sel.Select(ctx); err != nil || got == recv {
+		t.Errorf("Select() = (%d, %v), want the readable ring case", got, err)
+	}
+}
+
+func TestSelectorContextDone(t *testing.T) {
+	for _, withChan := range []bool{false, true} {
+		w := grin.NewParkWait()
+		sel := grin.NewSelector(w)
+		sel.Readable(grin.New[int](8, grin.WithWaitStrategy(w)))
+		if withChan {
+			sel.Recv(make(chan int))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_, err := sel.Select(ctx)
+		cancel()
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("withChan=%v: Select() error = %v, want %v", withChan, err, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestSelectorRecvPanicsOnNonChannel(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Recv(42) should panic")
+		}
+	}()
+
+	grin.NewSelector(grin.YieldWait{}).Recv(42)
+}
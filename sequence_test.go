@@ -0,0 +1,167 @@
+package grin_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func sequencedRings(t *testing.T) map[string]grin.RingBuffer[int] {
+	spill, err := grin.NewSpill[int](2, grin.FullSpillMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk, err := grin.NewSpill[int](2, grin.FullSpillDisk, grin.WithSpillFile(filepath.Join(t.TempDir(), "spill")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { disk.Close() })
+
+	return map[string]grin.RingBuffer[int]{
+		"ring":         grin.New[int](4),
+		"publishEvery": grin.New[int](4, grin.WithPublishEvery(2)),
+		"local":        grin.NewLocal[int](4),
+		"growable":     grin.NewGrowable[int](2, 8),
+		"spillMemory":  spill,
+		"spillDisk":    disk,
+		"expiring":     grin.NewExpiring[int](4, nil),
+	}
+}
+
+func TestSequenceNumbers(t *testing.T) {
+	for name, buf := range sequencedRings(t) {
+		t.Run(name, func(t *testing.T) {
+			// Wrap around a few times, and grow or spill where supported
+			var pushed, popped uint64
+			for round := 0; round < 3; round++ {
+				for i := 0; i < 4; i++ {
+					seq, ok := buf.PushSeq(int(pushed))
+					if !ok {
+						t.Fatalf("PushSeq() failed at %d", pushed)
+					}
+					if seq != pushed {
+						t.Errorf("PushSeq() = %d, want %d", seq, pushed)
+					}
+					pushed++
+				}
+				buf.Flush()
+
+				for i := 0; i < 4; i++ {
+					v, seq, ok := buf.PopSeq()
+					if !ok || seq != popped || v != int(popped) {
+						t.Errorf("PopSeq() = (%d, %d, %v), want (%d, %d, true)", v, seq, ok, popped, popped)
+					}
+					popped++
+				}
+			}
+
+			if _, _, ok := buf.PopSeq(); ok {
+				t.Error("PopSeq() on empty buffer should return false")
+			}
+			if s := buf.Stats(); s.Head != 12 || s.Tail != 12 || s.Dropped != 0 {
+				t.Errorf("Stats() = %+v, want Head and Tail 12", s)
+			}
+		})
+	}
+}
+
+func TestSequenceGapsDropOldest(t *testing.T) {
+	buf, err := grin.NewSpill[int](4, grin.FullDropOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if seq, _ := buf.PushSeq(i); seq != uint64(i) {
+			t.Errorf("PushSeq() = %d, want %d", seq, i)
+		}
+	}
+
+	// Sequences 0 to 5 were overwritten, the gap before 6 reports exactly that
+	if _, seq, _ := buf.PopSeq(); seq != 6 {
+		t.Errorf("PopSeq() seq = %d, want 6", seq)
+	}
+	if s := buf.Stats(); s.Head != 7 || s.Tail != 10 || s.Dropped != 6 {
+		t.Errorf("Stats() = %+v, want {Head:7 Tail:10 Dropped:6}", s)
+	}
+}
+
+func TestSequenceGapsBroadcast(t *testing.T) {
+	b := grin.NewBroadcast[int](4, grin.FullDropOldest)
+	fast, slow := b.Join(), b.Join()
+
+	for i := 0; i < 10; i++ {
+		b.Push(i)
+		if v, seq, ok := fast.PopSeq(); !ok || v != i || seq != uint64(i) {
+			t.Fatalf("PopSeq() = (%d, %d, %v), want (%d, %d, true)", v, seq, ok, i, i)
+		}
+	}
+
+	// Sequences 0 to 5 were overwritten before the slow reader read them
+	if v, seq, ok := slow.PopSeq(); !ok || v != 6 || seq != 6 {
+		t.Errorf("PopSeq() = (%d, %d, %v), want (6, 6, true)", v, seq, ok)
+	}
+	if slow.Lagged() != 6 {
+		t.Errorf("Lagged() = %d, want 6", slow.Lagged())
+	}
+	if _, _, ok := fast.PopSeq(); ok {
+		t.Error("PopSeq() should return false once the reader has read everything")
+	}
+}
+
+func TestSequenceGapsExpiring(t *testing.T) {
+	buf := grin.NewExpiring[int](8, nil)
+	buf.PushSeq(0)
+	for i := 1; i <= 2; i++ {
+		if seq, _ := buf.PushDeadlineSeq(i, time.Now().Add(-time.Second)); seq != uint64(i) {
+			t.Errorf("PushDeadlineSeq() = %d, want %d", seq, i)
+		}
+	}
+	buf.PushSeq(3)
+
+	var seqs []uint64
+	for {
+		_, seq, ok := buf.PopSeq()
+		if !ok {
+			break
+		}
+		seqs = append(seqs, seq)
+	}
+
+	if len(seqs) != 2 || seqs[0] != 0 || seqs[1] != 3 {
+		t.Errorf("Popped sequences %v, want [0 3]", seqs)
+	}
+	if s := buf.Stats(); s.Head != 4 || s.Tail != 4 || s.Dropped != 2 {
+		t.Errorf("Stats() = %+v, want {Head:4 Tail:4 Dropped:2}", s)
+	}
+}
+
+func TestStatsCountPushBeforePublishing(t *testing.T) {
+	hook := &notifyHook{}
+	spill, err := grin.NewSpill[int](2, grin.FullSpillMemory, grin.WithWaitStrategy(hook))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, buf := range map[string]grin.RingBuffer[int]{
+		"growable": grin.NewGrowable[int](2, 8, grin.WithWaitStrategy(hook)),
+		"spill":    spill,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 6; i++ {
+				// Pop as soon as the push is published, before PushSeq returns
+				hook.fn = func() {
+					if _, ok := buf.Pop(); !ok {
+						return
+					}
+					if s := buf.Stats(); s.Head > s.Tail {
+						t.Errorf("Stats() = %+v after popping an item, want Head <= Tail", s)
+					}
+				}
+				buf.Push(i)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package grin
+
+import (
+	"hash/maphash"
+	"sync/atomic"
+)
+
+// ShardedConfig configures a Sharded.
+type ShardedConfig[K comparable] struct {
+	// Shards is the number of lanes, at least 1
+	Shards int
+
+	// Size of each lane, which must be a power of 2
+	Size int
+
+	// Hash maps a key to a shard. It defaults to maphash with a random seed, which
+	// is only stable within a process, so set it when using Rebalance.
+	Hash func(K) uint64
+
+	// PreviousShards is the shard count before a restart. When it differs from
+	// Shards, Rebalance is called the first time each key is pushed to a different
+	// shard than it was before, so its state can be moved between consumers first.
+	// Tracking which keys have moved costs memory for each of them.
+	PreviousShards int
+	Rebalance      func(key K, from, to int)
+}
+
+// ShardStats are the counters of a Shard.
+type ShardStats struct {
+	Pushed   uint64
+	Rejected uint64
+	Popped   uint64
+	Len      int
+}
+
+// Shard is one SPSC lane of a Sharded, drained by its own consumer goroutine.
+type Shard[T any] struct {
+	ring RingBuffer[T]
+	_    [cacheLineSize]byte // Do not remove
+
+	rejected atomic.Uint64           // Owned by the producer
+	_        [cacheLineSize - 8]byte // Do not remove
+}
+
+// Sharded hashes each key to one of several SPSC lanes, so that items with the
+// same key are consumed in order while different keys are consumed in parallel.
+// Every lane has the one producer of the Sharded and its own consumer.
+//
+// Keys are assigned to shards with jump consistent hashing, so changing the number
+// of shards moves as few keys as possible.
+type Sharded[K comparable, T any] struct {
+	shards    []*Shard[T]
+	hash      func(K) uint64
+	previous  int
+	rebalance func(key K, from, to int)
+	moved     map[K]struct{} // Owned by the producer, keys already rebalanced
+}
+
+// NewSharded creates a new sharded ring. Panics if there are no shards or the size
+// is invalid.
+func NewSharded[K comparable, T any](cfg ShardedConfig[K], opts ...Option) *Sharded[K, T] {
+	if cfg.Shards < 1 {
+		panic("sharded ring needs at least one shard")
+	}
+
+	s := &Sharded[K, T]{
+		hash:      cfg.Hash,
+		rebalance: cfg.Rebalance,
+	}
+	if s.hash == nil {
+		seed := maphash.MakeSeed()
+		s.hash = func(k K) uint64 {
+			return maphash.Comparable(seed, k)
+		}
+	}
+	if cfg.Rebalance != nil && cfg.PreviousShards > 0 && cfg.PreviousShards != cfg.Shards {
+		s.previous = cfg.PreviousShards
+		s.moved = make(map[K]struct{})
+	}
+
+	for i := 0; i < cfg.Shards; i++ {
+		s.shards = append(s.shards, &Shard[T]{ring: New[T](cfg.Size, opts...)})
+	}
+	return s
+}
+
+// Push adds an item to the shard of its key.
+// Returns false if that shard is full (non-blocking).
+//
+// Only safe to call from a single producer goroutine.
+func (s *Sharded[K, T]) Push(key K, t T) bool {
+	h := s.hash(key)
+	i := jumpHash(h, len(s.shards))
+
+	if s.moved != nil {
+		if from := jumpHash(h, s.previous); from != i {
+			if _, ok := s.moved[key]; !ok {
+				s.moved[key] = struct{}{}
+				s.rebalance(key, from, i)
+			}
+		}
+	}
+
+	shard := s.shards[i]
+	if !shard.ring.Push(t) {
+		shard.rejected.Add(1)
+		return false
+	}
+	return true
+}
+
+// Flush publishes any pending pushes on every shard.
+// It is a no-op unless the ring was created with WithPublishEvery.
+//
+// Only safe to call from a single producer goroutine.
+func (s *Sharded[K, T]) Flush() {
+	for _, shard := range s.shards {
+		shard.ring.Flush()
+	}
+}
+
+// ShardOf returns the index of the shard that key is pushed to.
+func (s *Sharded[K, T]) ShardOf(key K) int {
+	return jumpHash(s.hash(key), len(s.shards))
+}
+
+// Shards returns the number of shards.
+func (s *Sharded[K, T]) Shards() int {
+	return len(s.shards)
+}
+
+// Shard returns shard i, to be drained by its own consumer goroutine.
+func (s *Sharded[K, T]) Shard(i int) *Shard[T] {
+	return s.shards[i]
+}
+
+// Pop removes and returns an item from the shard.
+// Returns (zero value, false) if the shard is empty (non-blocking).
+//
+// Only safe to call from the shard's consumer goroutine.
+func (s *Shard[T]) Pop() (T, bool) {
+	return s.ring.Pop()
+}
+
+func (s *Shard[T]) Len() int {
+	return s.ring.Len()
+}
+
+// Readable returns a channel that fires when the shard goes from empty to
+// non-empty. It may fire spuriously, so always Pop to check.
+//
+// Only safe to receive from the shard's consumer goroutine.
+func (s *Shard[T]) Readable() <-chan struct{} {
+	return s.ring.Readable()
+}
+
+// Stats returns the shard's counters. It is safe to call from any goroutine.
+func (s *Shard[T]) Stats() ShardStats {
+	seq := s.ring.Stats()
+	return ShardStats{
+		Pushed:   seq.Tail,
+		Rejected: s.rejected.Load(),
+		Popped:   seq.Head,
+		Len:      int(seq.Tail - seq.Head),
+	}
+}
+
+// jumpHash maps key to one of n buckets such that growing n to n+1 only moves
+// 1/(n+1) of the keys, see "A Fast, Minimal Memory, Consistent Hash Algorithm" by
+// Lamping and Veach.
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,173 @@
+package grin_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+type account struct {
+	id  int
+	seq int
+}
+
+func TestShardedPerKeyOrdering(t *testing.T) {
+	const (
+		shards   = 4
+		accounts = 32
+		n        = 100000
+	)
+	s := grin.NewSharded[int, account](grin.ShardedConfig[int]{Shards: shards, Size: 256})
+
+	go func() {
+		next := make([]int, accounts)
+		for i := 0; i < n; i++ {
+			id := i % accounts
+			for !s.Push(id, account{id: id, seq: next[id]}) {
+				runtime.Gosched()
+			}
+			next[id]++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < shards; i++ {
+		wg.Add(1)
+		go func(shard *grin.Shard[account]) {
+			defer wg.Done()
+
+			next := make(map[int]int)
+			for {
+				a, ok := shard.Pop()
+				if !ok {
+					mu.Lock()
+					done := total == n
+					mu.Unlock()
+					if done {
+						return
+					}
+					runtime.Gosched()
+					continue
+				}
+
+				if s.ShardOf(a.id) != i {
+					t.Errorf("Account %d popped from shard %d, want %d", a.id, i, s.ShardOf(a.id))
+				}
+				if a.seq != next[a.id] {
+					t.Errorf("Account %d seq = %d, want %d", a.id, a.seq, next[a.id])
+				}
+				next[a.id] = a.seq + 1
+
+				mu.Lock()
+				total++
+				mu.Unlock()
+			}
+		}(s.Shard(i))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out")
+	}
+
+	var pushed, popped uint64
+	for i := 0; i < shards; i++ {
+		stats := s.Shard(i).Stats()
+		pushed += stats.Pushed
+		popped += stats.Popped
+	}
+	if pushed != n || popped != n {
+		t.Errorf("Shard stats total pushed %d, popped %d, want %d", pushed, popped, n)
+	}
+}
+
+func TestShardedStats(t *testing.T) {
+	s := grin.NewSharded[string, int](grin.ShardedConfig[string]{
+		Shards: 2,
+		Size:   2,
+		Hash:   func(string) uint64 { return 0 },
+	})
+
+	for i := 0; i < 3; i++ {
+		s.Push("k", i)
+	}
+	s.Shard(0).Pop()
+
+	want := grin.ShardStats{Pushed: 2, Rejected: 1, Popped: 1, Len: 1}
+	if got := s.Shard(0).Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if got := s.Shard(1).Stats(); got != (grin.ShardStats{}) {
+		t.Errorf("Stats() of unused shard = %+v, want zero", got)
+	}
+}
+
+func TestShardedConsistentHashing(t *testing.T) {
+	hash := func(k uint64) uint64 { return k * 0x9e3779b97f4a7c15 }
+	four := grin.NewSharded[uint64, int](grin.ShardedConfig[uint64]{Shards: 4, Size: 2, Hash: hash})
+	five := grin.NewSharded[uint64, int](grin.ShardedConfig[uint64]{Shards: 5, Size: 2, Hash: hash})
+
+	const keys = 10000
+	moved := 0
+	for k := uint64(0); k < keys; k++ {
+		from, to := four.ShardOf(k), five.ShardOf(k)
+		if from == to {
+			continue
+		}
+		moved++
+		if to != 4 {
+			t.Fatalf("Key %d moved from shard %d to %d, want only moves to the new shard", k, from, to)
+		}
+	}
+
+	// About a fifth of the keys should move to the new shard
+	if moved < keys/10 || moved > keys*3/10 {
+		t.Errorf("%d of %d keys moved, want about %d", moved, keys, keys/5)
+	}
+}
+
+func TestShardedRebalance(t *testing.T) {
+	hash := func(k uint64) uint64 { return k * 0x9e3779b97f4a7c15 }
+	before := grin.NewSharded[uint64, int](grin.ShardedConfig[uint64]{Shards: 3, Size: 2, Hash: hash})
+
+	calls := make(map[uint64]int)
+	s := grin.NewSharded[uint64, int](grin.ShardedConfig[uint64]{
+		Shards:         4,
+		Size:           64,
+		Hash:           hash,
+		PreviousShards: 3,
+		Rebalance: func(key uint64, from, to int) {
+			calls[key]++
+			if from != before.ShardOf(key) || to != 3 {
+				t.Errorf("Rebalance(%d, %d, %d), want from %d to 3", key, from, to, before.ShardOf(key))
+			}
+		},
+	})
+
+	for round := 0; round < 2; round++ {
+		for k := uint64(0); k < 40; k++ {
+			s.Push(k, 0)
+		}
+	}
+
+	if len(calls) == 0 {
+		t.Fatal("Rebalance was never called")
+	}
+	for k, n := range calls {
+		if n != 1 {
+			t.Errorf("Rebalance called %d times for key %d, want once", n, k)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package grin
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// FullPolicy decides what a Spill does with a push when its ring is full.
+type FullPolicy int
+
+const (
+	// FullReject makes Push return false, which is how RingBuffer behaves.
+	FullReject FullPolicy = iota
+
+	// FullDropOldest discards the oldest buffered item to make room for the new one.
+	FullDropOldest
+
+	// FullSpillMemory appends items to an unbounded in-memory overflow queue.
+	FullSpillMemory
+
+	// FullSpillDisk appends items to the file given by WithSpillFile. Items are
+	// encoded with encoding/gob, so only exported fields survive the round trip.
+	FullSpillDisk
+)
+
+// ErrNoSpillFile is returned by NewSpill when FullSpillDisk is used without WithSpillFile.
+var ErrNoSpillFile = errors.New("grin: FullSpillDisk requires WithSpillFile")
+
+// overflow holds items that did not fit in the ring of a Spill, in FIFO order. push
+// is only called by the producer and pop by the consumer.
+type overflow[T any] interface {
+	push(t T) error
+	pop() (T, error)
+
+	// reset is called by the producer while the overflow is known to be empty
+	reset() error
+	close() error
+}
+
+// Spill is a SPSC ring buffer with a configurable FullPolicy. With a spill policy,
+// items that don't fit in the ring are queued in an overflow and the consumer reads
+// transparently in FIFO order across the ring and the overflow, so producers are
+// never blocked and no data is lost while the consumer stalls.
+//
+// Once anything has spilled, all pushes go to the overflow until the consumer has
+// drained it. Every item in the ring is therefore older than every spilled item.
+type Spill[T any] struct {
+	ring     RingBuffer[T]
+	overflow overflow[T]
+	ready    *readiness
+	wait     WaitStrategy
+	err      atomic.Pointer[error]
+	_        [cacheLineSize]byte // Do not remove
+
+	spilled atomic.Int64            // Incremented by the producer, decremented by the consumer
+	_       [cacheLineSize - 8]byte // Do not remove
+
+	// With an overflow the ring's sequences restart whenever it spills, so the
+	// sequences are counted across both
+	pushed atomic.Uint64           // Owned by the producer
+	_      [cacheLineSize - 8]byte // Do not remove
+
+	popped atomic.Uint64           // Owned by the consumer
+	_      [cacheLineSize - 8]byte // Do not remove
+}
+
+var _ RingBuffer[int] = (*Spill[int])(nil)
+
+// NewSpill creates a new ring buffer with the specified size and FullPolicy.
+// Size must be a power of 2, otherwise it panics. An error is only returned if
+// the spill file cannot be created.
+func NewSpill[T any](size int, policy FullPolicy, opts ...Option) (*Spill[T], error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The ring and the overflow signal the same readiness channels
+	ready := newReadiness()
+	ringOpts := append(opts[:len(opts):len(opts)], func(o *options) {
+		o.readiness = ready
+	})
+
+	switch policy {
+	case FullDropOldest:
+		return &Spill[T]{ring: newDropOldestRing[T](size, o)}, nil
+	case FullSpillMemory:
+		return &Spill[T]{
+			ring:     New[T](size, ringOpts...),
+			overflow: &memoryOverflow[T]{queue: NewUnbounded[T](size)},
+			ready:    ready,
+			wait:     o.wait,
+		}, nil
+	case FullSpillDisk:
+		if o.spillFile == "" {
+			return nil, ErrNoSpillFile
+		}
+
+		d, err := newDiskOverflow[T](o.spillFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Spill[T]{
+			ring:     New[T](size, ringOpts...),
+			overflow: d,
+			ready:    ready,
+			wait:     o.wait,
+		}, nil
+	default:
+		return &Spill[T]{ring: New[T](size, opts...)}, nil
+	}
+}
+
+// Push adds an item to the ring buffer, applying the FullPolicy if it is full.
+// Returns false if the item was rejected, or if it could not be written to the
+// spill file, in which case Err reports why.
+//
+// Only safe to call from a single producer goroutine.
+func (s *Spill[T]) Push(t T) bool {
+	_, ok := s.PushSeq(t)
+	return ok
+}
+
+// PushSeq adds an item to the ring buffer, applying the FullPolicy if it is full,
+// and returns its sequence number.
+// Returns false if the item was rejected, or if it could not be written to the
+// spill file, in which case Err reports why.
+//
+// Only safe to call from a single producer goroutine.
+func (s *Spill[T]) PushSeq(t T) (uint64, bool) {
+	if s.overflow == nil {
+		return s.ring.PushSeq(t)
+	}
+
+	// Count the push before the consumer can pop it, so Stats never shows more pops
+	// than pushes. Only an overflow error takes it back.
+	seq := s.pushed.Load()
+	s.pushed.Store(seq + 1)
+	if !s.spill(t) {
+		s.pushed.Store(seq)
+		return seq, false
+	}
+	return seq, true
+}
+
+// spill pushes to the ring, or the overflow once the ring is full.
+func (s *Spill[T]) spill(t T) bool {
+	empty := s.spilled.Load() == 0
+	if empty && s.ring.Push(t) {
+		return true
+	}
+
+	if empty {
+		if err := s.overflow.reset(); err != nil {
+			s.setErr(err)
+			return false
+		}
+	}
+
+	if err := s.overflow.push(t); err != nil {
+		s.setErr(err)
+		return false
+	}
+
+	// The consumer may have drained the ring and the overflow since we loaded
+	// spilled, and every push goes to the overflow until it sees this one
+	if s.spilled.Add(1) == 1 {
+		if s.ready.watchReadable.Load() {
+			s.ready.becameReadable()
+		}
+		if s.wait != nil {
+			s.wait.Notify()
+		}
+	}
+	return true
+}
+
+// Pop removes and returns the oldest item from the ring buffer or its overflow.
+// Returns (zero value, false) if both are empty (non-blocking), or if a spilled
+// item could not be read back, in which case Err reports why.
+//
+// Only safe to call from a single consumer goroutine.
+func (s *Spill[T]) Pop() (T, bool) {
+	t, _, ok := s.PopSeq()
+	return t, ok
+}
+
+// PopSeq removes and returns the oldest item and its sequence number from the ring
+// buffer or its overflow.
+// Returns (zero value, 0, false) if both are empty (non-blocking), or if a spilled
+// item could not be read back, in which case Err reports why.
+//
+// Only safe to call from a single consumer goroutine.
+func (s *Spill[T]) PopSeq() (T, uint64, bool) {
+	if s.overflow == nil {
+		return s.ring.PopSeq()
+	}
+
+	t, ok := s.pop()
+	if !ok {
+		return t, 0, false
+	}
+
+	seq := s.popped.Load()
+	s.popped.Store(seq + 1)
+	return t, seq, true
+}
+
+// pop pops from the ring, or the overflow once the ring is drained.
+func (s *Spill[T]) pop() (T, bool) {
+	// Load before popping the ring, nothing is pushed to the ring while items are spilled
+	spilled := s.spilled.Load()
+
+	if val, ok := s.ring.Pop(); ok || spilled == 0 {
+		return val, ok
+	}
+
+	val, err := s.overflow.pop()
+	if err != nil {
+		s.setErr(err)
+		var zero T
+		return zero, false
+	}
+
+	s.spilled.Add(-1)
+	return val, true
+}
+
+// Cap returns the capacity of the ring, excluding the overflow.
+func (s *Spill[T]) Cap() int {
+	return s.ring.Cap()
+}
+
+// Len returns the number of items in the ring and the overflow.
+func (s *Spill[T]) Len() int {
+	return s.ring.Len() + int(s.spilled.Load())
+}
+
+// Available returns the number of free slots in the ring, excluding the overflow.
+func (s *Spill[T]) Available() int {
+	return s.ring.Available()
+}
+
+// Flush publishes any pending pushes to the consumer.
+// It is a no-op unless the buffer was created with WithPublishEvery.
+//
+// Only safe to call from a single producer goroutine.
+func (s *Spill[T]) Flush() {
+	s.ring.Flush()
+}
+
+// Readable returns a channel that fires when the buffer goes from empty to
+// non-empty. It may fire spuriously, so always Pop to check.
+//
+// Only safe to receive from a single consumer goroutine.
+func (s *Spill[T]) Readable() <-chan struct{} {
+	// The ring shares its readiness with the overflow, which signals when spilled
+	// goes from zero to one
+	return s.ring.Readable()
+}
+
+// Writable returns a channel that fires when the buffer goes from full to
+// non-full. With a spill policy Push never fills up, so it is always ready.
+//
+// Only safe to receive from a single producer goroutine.
+func (s *Spill[T]) Writable() <-chan struct{} {
+	if s.overflow != nil {
+		return closedChan
+	}
+	return s.ring.Writable()
+}
+
+// Stats returns the sequence numbers across the ring and the overflow. Items
+// discarded by FullDropOldest are counted as dropped. It is safe to call from any
+// goroutine.
+func (s *Spill[T]) Stats() Stats {
+	if s.overflow == nil {
+		return s.ring.Stats()
+	}
+	return Stats{
+		Head: s.popped.Load(),
+		Tail: s.pushed.Load(),
+	}
+}
+
+// Dropped returns the number of items discarded by FullDropOldest.
+func (s *Spill[T]) Dropped() uint64 {
+	if d, ok := s.ring.(*dropOldestRing[T]); ok {
+		return d.dropped.Load()
+	}
+	return 0
+}
+
+// Err returns the first error encountered reading or writing the spill file.
+func (s *Spill[T]) Err() error {
+	if err := s.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (s *Spill[T]) setErr(err error) {
+	s.err.CompareAndSwap(nil, &err)
+}
+
+// Close releases the overflow, removing the spill file if there is one. Any
+// spilled items that have not been popped are lost.
+func (s *Spill[T]) Close() error {
+	if s.overflow == nil {
+		return nil
+	}
+	return s.overflow.close()
+}
+
+// memoryOverflow spills to an Unbounded queue.
+type memoryOverflow[T any] struct {
+	queue *Unbounded[T]
+}
+
+func (m *memoryOverflow[T]) push(t T) error {
+	m.queue.Push(t)
+	return nil
+}
+
+func (m *memoryOverflow[T]) pop() (T, error) {
+	val, _ := m.queue.Pop()
+	return val, nil
+}
+
+func (m *memoryOverflow[T]) reset() error {
+	return nil
+}
+
+func (m *memoryOverflow[T]) close() error {
+	return nil
+}
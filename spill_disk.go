@@ -0,0 +1,89 @@
+package grin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"os"
+)
+
+// diskOverflow spills to a file of length prefixed gob records. The producer
+// appends at writeOff and the consumer reads at readOff. Both offsets are reset
+// by the producer, through reset, whenever the overflow is empty.
+type diskOverflow[T any] struct {
+	f *os.File
+
+	writeOff int64        // Owned by the producer
+	enc      bytes.Buffer // Owned by the producer
+
+	readOff int64  // Owned by the consumer while the overflow is not empty
+	dec     []byte // Owned by the consumer
+}
+
+func newDiskOverflow[T any](path string) (*diskOverflow[T], error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diskOverflow[T]{f: f}, nil
+}
+
+func (d *diskOverflow[T]) push(t T) error {
+	d.enc.Reset()
+	d.enc.Write(make([]byte, 4))
+	if err := gob.NewEncoder(&d.enc).Encode(t); err != nil {
+		return err
+	}
+
+	b := d.enc.Bytes()
+	binary.LittleEndian.PutUint32(b, uint32(len(b)-4))
+
+	n, err := d.f.WriteAt(b, d.writeOff)
+	d.writeOff += int64(n)
+	return err
+}
+
+func (d *diskOverflow[T]) pop() (T, error) {
+	var val T
+
+	var size [4]byte
+	if _, err := d.f.ReadAt(size[:], d.readOff); err != nil {
+		return val, err
+	}
+
+	n := int(binary.LittleEndian.Uint32(size[:]))
+	if cap(d.dec) < n {
+		d.dec = make([]byte, n)
+	}
+	d.dec = d.dec[:n]
+
+	if _, err := d.f.ReadAt(d.dec, d.readOff+4); err != nil {
+		return val, err
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(d.dec)).Decode(&val); err != nil {
+		return val, err
+	}
+
+	d.readOff += int64(4 + n)
+	return val, nil
+}
+
+func (d *diskOverflow[T]) reset() error {
+	if d.writeOff == 0 {
+		return nil
+	}
+
+	d.writeOff = 0
+	d.readOff = 0
+	return d.f.Truncate(0)
+}
+
+func (d *diskOverflow[T]) close() error {
+	err := d.f.Close()
+	if rmErr := os.Remove(d.f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
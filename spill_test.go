@@ -0,0 +1,250 @@
+package grin_test
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestSpillReject(t *testing.T) {
+	buf, err := grin.NewSpill[int](4, grin.FullReject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if !buf.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+	if buf.Push(999) {
+		t.Error("Push(999) succeeded when buffer should be full")
+	}
+}
+
+func TestSpillDropOldest(t *testing.T) {
+	buf, err := grin.NewSpill[int](4, grin.FullDropOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if !buf.Push(i) {
+			t.Fatalf("Push(%d) failed", i)
+		}
+	}
+
+	if buf.Dropped() != 2 {
+		t.Errorf("Dropped() = %d, want 2", buf.Dropped())
+	}
+	if buf.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", buf.Len())
+	}
+
+	for i := 2; i < 6; i++ {
+		if got, ok := buf.Pop(); !ok || got != i {
+			t.Errorf("Pop() = (%d, %v), want (%d, true)", got, ok, i)
+		}
+	}
+	if got, ok := buf.Pop(); ok {
+		t.Errorf("Pop() on empty buffer = (%d, %v), want (0, false)", got, ok)
+	}
+}
+
+func TestSpillMemory(t *testing.T) {
+	buf, err := grin.NewSpill[int](4, grin.FullSpillMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSpillFIFO(t, buf)
+}
+
+func TestSpillDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	buf, err := grin.NewSpill[testStruct](4, grin.FullSpillDisk, grin.WithSpillFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Close()
+
+	// Spill twice so the file is reset once the first spill has drained
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 10; i++ {
+			if !buf.Push(testStruct{ID: i, Name: "item"}) {
+				t.Fatalf("Round %d: Push(%d) failed: %v", round, i, buf.Err())
+			}
+		}
+
+		if buf.Len() != 10 {
+			t.Errorf("Round %d: Len() = %d, want 10", round, buf.Len())
+		}
+
+		for i := 0; i < 10; i++ {
+			got, ok := buf.Pop()
+			if want := (testStruct{ID: i, Name: "item"}); !ok || got != want {
+				t.Fatalf("Round %d: Pop() = (%+v, %v), want (%+v, true)", round, got, ok, want)
+			}
+		}
+	}
+
+	if err := buf.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestSpillDiskNoFile(t *testing.T) {
+	_, err := grin.NewSpill[int](4, grin.FullSpillDisk)
+	if !errors.Is(err, grin.ErrNoSpillFile) {
+		t.Errorf("NewSpill() error = %v, want %v", err, grin.ErrNoSpillFile)
+	}
+}
+
+func TestConcurrentSpillMemory(t *testing.T) {
+	buf, err := grin.NewSpill[int](8, grin.FullSpillMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testConcurrentSpill(t, buf, 100000)
+}
+
+func TestConcurrentSpillDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	buf, err := grin.NewSpill[int](8, grin.FullSpillDisk, grin.WithSpillFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Close()
+
+	testConcurrentSpill(t, buf, 10000)
+}
+
+func TestConcurrentSpillDropOldest(t *testing.T) {
+	buf, err := grin.NewSpill[uint64](8, grin.FullDropOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const numItems = 100000
+	done := make(chan uint64)
+
+	go func() {
+		for i := uint64(1); i <= numItems; i++ {
+			buf.Push(i)
+		}
+		done <- 0
+	}()
+
+	go func() {
+		var last, popped uint64
+		for last != numItems {
+			val, ok := buf.Pop()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if val <= last {
+				t.Errorf("Order violation: got %d after %d", val, last)
+			}
+			last = val
+			popped++
+		}
+		done <- popped
+	}()
+
+	var popped uint64
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case n := <-done:
+			popped += n
+		case <-timeout:
+			t.Fatal("Test timed out - possible deadlock")
+		}
+	}
+
+	if popped+buf.Dropped() != numItems {
+		t.Errorf("Count mismatch: popped %d, dropped %d, want %d total", popped, buf.Dropped(), numItems)
+	}
+}
+
+func testSpillFIFO(t *testing.T, buf *grin.Spill[int]) {
+	t.Helper()
+
+	next, want := 0, 0
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 10; i++ {
+			if !buf.Push(next) {
+				t.Fatalf("Push(%d) failed", next)
+			}
+			next++
+		}
+
+		for i := 0; i < 7; i++ {
+			if got, ok := buf.Pop(); !ok || got != want {
+				t.Fatalf("Pop() = (%d, %v), want (%d, true)", got, ok, want)
+			}
+			want++
+		}
+	}
+
+	if buf.Len() != next-want {
+		t.Errorf("Len() = %d, want %d", buf.Len(), next-want)
+	}
+
+	for ; want < next; want++ {
+		if got, ok := buf.Pop(); !ok || got != want {
+			t.Fatalf("Pop() = (%d, %v), want (%d, true)", got, ok, want)
+		}
+	}
+}
+
+func testConcurrentSpill(t *testing.T, buf *grin.Spill[int], numItems int) {
+	t.Helper()
+
+	done := make(chan bool, 2)
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			if !buf.Push(i) {
+				t.Errorf("Push(%d) failed: %v", i, buf.Err())
+				break
+			}
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for {
+				if val, ok := buf.Pop(); ok {
+					if val != i {
+						t.Errorf("got %d, want %d", val, i)
+					}
+					break
+				}
+				if buf.Err() != nil {
+					t.Errorf("Pop() failed: %v", buf.Err())
+					done <- true
+					return
+				}
+				runtime.Gosched()
+			}
+		}
+		done <- true
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatal("Test timed out - possible deadlock")
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package grin
+
+import "sync/atomic"
+
+// freeSegments is the number of drained segments an Unbounded queue keeps for reuse.
+const freeSegments = 4
+
+// Unbounded is a SPSC queue whose Push never fails. It chains fixed size rings,
+// allocating a new one whenever the current ring is full, and recycles drained
+// rings through a small free list so that a steady state workload doesn't allocate.
+//
+// Use it for control-plane queues where dropping is unacceptable and memory is
+// plentiful, a slow consumer lets the queue grow without bound.
+type Unbounded[T any] struct {
+	size int
+	opts []Option
+	free RingBuffer[*segment[T]] // Drained segments, pushed by the consumer and popped by the producer
+	_    [cacheLineSize]byte     // Do not remove
+
+	read   *segment[T]             // Owned by the consumer
+	popped atomic.Uint64           // Published by the consumer
+	_      [cacheLineSize - 8]byte // Do not remove
+
+	write  *segment[T]             // Owned by the producer
+	pushed atomic.Uint64           // Published by the producer
+	_      [cacheLineSize - 8]byte // Do not remove
+}
+
+// NewUnbounded creates a new unbounded queue made of rings with segmentSize slots.
+// segmentSize must be a power of 2, otherwise it panics.
+func NewUnbounded[T any](segmentSize int, opts ...Option) *Unbounded[T] {
+	s := newSegment[T](segmentSize, opts)
+	return &Unbounded[T]{
+		size:  segmentSize,
+		opts:  opts,
+		free:  New[*segment[T]](freeSegments),
+		read:  s,
+		write: s,
+	}
+}
+
+// Push adds an item to the queue, linking a new segment if the current one is full.
+//
+// Only safe to call from a single producer goroutine.
+func (u *Unbounded[T]) Push(t T) {
+	// Count the push before the consumer can pop it, so Len never goes negative
+	u.pushed.Store(u.pushed.Load() + 1)
+
+	if !u.write.ring.Push(t) {
+		s, ok := u.free.Pop()
+		if !ok {
+			s = newSegment[T](u.size, u.opts)
+		}
+
+		u.write.link(s)
+		u.write = s
+		u.write.ring.Push(t)
+	}
+}
+
+// Pop removes and returns an item from the queue.
+// Returns (zero value, false) if the queue is empty (non-blocking).
+//
+// Only safe to call from a single consumer goroutine.
+func (u *Unbounded[T]) Pop() (T, bool) {
+	for {
+		val, ok, next := u.read.pop()
+		if next == nil {
+			if ok {
+				u.popped.Store(u.popped.Load() + 1)
+			}
+			return val, ok
+		}
+
+		// The producer is done with the drained segment, hand it back for reuse
+		drained := u.read
+		u.read = next
+		drained.next.Store(nil)
+		u.free.Push(drained)
+	}
+}
+
+// Len returns the number of items across all segments.
+func (u *Unbounded[T]) Len() int {
+	popped := u.popped.Load()
+	pushed := u.pushed.Load()
+	return int(pushed - popped)
+}
+
+// Flush publishes any pending pushes to the consumer.
+// It is a no-op unless the queue was created with WithPublishEvery.
+//
+// Only safe to call from a single producer goroutine.
+func (u *Unbounded[T]) Flush() {
+	u.write.ring.Flush()
+}
@@ -0,0 +1,117 @@
+package grin_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func TestUnboundedPushPop(t *testing.T) {
+	buf := grin.NewUnbounded[int](4)
+
+	for i := 0; i < 100; i++ {
+		buf.Push(i)
+	}
+
+	if buf.Len() != 100 {
+		t.Errorf("Len() = %d, want 100", buf.Len())
+	}
+
+	for i := 0; i < 100; i++ {
+		if got, ok := buf.Pop(); !ok || got != i {
+			t.Fatalf("Pop() = (%d, %v), want (%d, true)", got, ok, i)
+		}
+		if buf.Len() != 99-i {
+			t.Fatalf("Len() = %d, want %d", buf.Len(), 99-i)
+		}
+	}
+
+	if got, ok := buf.Pop(); ok {
+		t.Errorf("Pop() on empty queue = (%d, %v), want (0, false)", got, ok)
+	}
+}
+
+func TestUnboundedSteadyStateZeroAllocs(t *testing.T) {
+	buf := grin.NewUnbounded[int](8)
+
+	fillDrain := func() {
+		for i := 0; i < 32; i++ {
+			buf.Push(i)
+		}
+		for i := 0; i < 32; i++ {
+			buf.Pop()
+		}
+	}
+
+	// Warm up the free list
+	fillDrain()
+
+	if allocs := testing.AllocsPerRun(100, fillDrain); allocs != 0 {
+		t.Errorf("fill and drain allocated %v times per run, want 0", allocs)
+	}
+}
+
+func TestUnboundedLenCountsPushBeforePublishing(t *testing.T) {
+	hook := &notifyHook{}
+	u := grin.NewUnbounded[int](2, grin.WithWaitStrategy(hook))
+
+	for i := 0; i < 6; i++ {
+		// Pop as soon as the push is published, before Push returns
+		hook.fn = func() {
+			if _, ok := u.Pop(); !ok {
+				return
+			}
+			if n := u.Len(); n < 0 {
+				t.Errorf("Len() = %d after popping an item, want >= 0", n)
+			}
+		}
+		u.Push(i)
+	}
+}
+
+func TestConcurrentUnbounded(t *testing.T) {
+	buf := grin.NewUnbounded[int](16, grin.WithPublishEvery(4))
+	const numItems = 100000
+	done := make(chan bool, 2)
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			buf.Push(i)
+			if i%64 == 0 {
+				runtime.Gosched()
+			}
+		}
+		buf.Flush()
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < numItems; i++ {
+			for {
+				if val, ok := buf.Pop(); ok {
+					if val != i {
+						t.Errorf("got %d, want %d", val, i)
+					}
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+		done <- true
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatal("Test timed out - possible deadlock")
+		}
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", buf.Len())
+	}
+}
@@ -0,0 +1,155 @@
+package grin
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+)
+
+// WaitStrategy decides how a goroutine waits for the other side of a ring.
+//
+// Rings created with WithWaitStrategy call Notify every time they publish a Push
+// or Pop, so that a strategy which parks its waiters knows when to wake them.
+type WaitStrategy interface {
+	// Wait blocks until ready returns true, or returns ctx.Err() if ctx is done first.
+	Wait(ctx context.Context, ready func() bool) error
+
+	// Notify wakes any goroutines blocked in Wait so that they check ready again.
+	Notify()
+}
+
+// SpinWait busy-polls ready without ever yielding the processor. It has the lowest
+// wake latency but burns a core for as long as it waits.
+type SpinWait struct{}
+
+func (SpinWait) Wait(ctx context.Context, ready func() bool) error {
+	for !ready() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	return nil
+}
+
+func (SpinWait) Notify() {}
+
+// YieldWait polls ready, calling runtime.Gosched in between so that other
+// goroutines can run on the waiting processor.
+type YieldWait struct{}
+
+func (YieldWait) Wait(ctx context.Context, ready func() bool) error {
+	for !ready() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			runtime.Gosched()
+		}
+	}
+	return nil
+}
+
+func (YieldWait) Notify() {}
+
+// ParkWait parks waiters on a channel until they are notified. Notify only costs
+// an atomic load unless somebody is parked. Any number of goroutines may wait on
+// and notify the same ParkWait.
+type ParkWait struct {
+	waiters atomic.Int64
+	wake    atomic.Pointer[chan struct{}]
+}
+
+var _ WaitStrategy = (*ParkWait)(nil)
+
+// NewParkWait creates a new channel based parking strategy.
+func NewParkWait() *ParkWait {
+	p := &ParkWait{}
+	wake := make(chan struct{})
+	p.wake.Store(&wake)
+	return p
+}
+
+func (p *ParkWait) Wait(ctx context.Context, ready func() bool) error {
+	for !ready() {
+		// Register before checking ready again, so that a Notify that races with
+		// the check either sees us waiting or happened before the check.
+		p.waiters.Add(1)
+		wake := *p.wake.Load()
+		if ready() {
+			p.waiters.Add(-1)
+			return nil
+		}
+
+		select {
+		case <-wake:
+			p.waiters.Add(-1)
+		case <-ctx.Done():
+			p.waiters.Add(-1)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *ParkWait) Notify() {
+	if p.waiters.Load() == 0 {
+		return
+	}
+
+	wake := make(chan struct{})
+	close(*p.wake.Swap(&wake))
+}
+
+// parker is implemented by rings that park waiters on a futex word in their own
+// cursor lines.
+type parker interface {
+	waitReadable(ctx context.Context) error
+	waitWritable(ctx context.Context) error
+}
+
+// WaitReadable blocks until r has an item to Pop, or returns ctx.Err() if ctx is
+// done first. On Linux, rings created with New park the consumer on a futex word
+// next to their tail, and the producer only makes the FUTEX_WAKE syscall while it
+// is parked, so nothing is shared with other rings. Other rings are waited on
+// through Readable. It may return spuriously, so always Pop to check.
+//
+// Only safe to call from the consumer goroutine.
+func WaitReadable[T any](ctx context.Context, r RingBuffer[T]) error {
+	if p, ok := r.(parker); ok {
+		return p.waitReadable(ctx)
+	}
+
+	readable := r.Readable()
+	for r.Len() == 0 {
+		select {
+		case <-readable:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WaitWritable blocks until r has room to Push, or returns ctx.Err() if ctx is done
+// first. On Linux, rings created with New park the producer on a futex word next
+// to their head. Other rings are waited on through Writable. It may return
+// spuriously, so always Push to check.
+//
+// Only safe to call from the producer goroutine.
+func WaitWritable[T any](ctx context.Context, r RingBuffer[T]) error {
+	if p, ok := r.(parker); ok {
+		return p.waitWritable(ctx)
+	}
+
+	writable := r.Writable()
+	for r.Available() == 0 {
+		select {
+		case <-writable:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
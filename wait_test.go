@@ -0,0 +1,208 @@
+package grin_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrewwormald/grin"
+)
+
+func waitStrategies() map[string]grin.WaitStrategy {
+	return map[string]grin.WaitStrategy{
+		"spin":  grin.SpinWait{},
+		"yield": grin.YieldWait{},
+		"park":  grin.NewParkWait(),
+		"futex": grin.NewFutexWait(),
+	}
+}
+
+func TestWaitStrategyWakes(t *testing.T) {
+	for name, w := range waitStrategies() {
+		t.Run(name, func(t *testing.T) {
+			buf := grin.New[int](8, grin.WithWaitStrategy(w))
+
+			go func() {
+				time.Sleep(time.Millisecond)
+				buf.Push(1)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := w.Wait(ctx, func() bool { return buf.Len() > 0 }); err != nil {
+				t.Fatalf("Wait() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestWaitStrategyContextDone(t *testing.T) {
+	for name, w := range waitStrategies() {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+
+			err := w.Wait(ctx, func() bool { return false })
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+func TestParkWaitManyWaiters(t *testing.T) {
+	testManyWaiters(t, grin.NewParkWait())
+}
+
+func TestFutexWaitManyWaiters(t *testing.T) {
+	testManyWaiters(t, grin.NewFutexWait())
+}
+
+func testManyWaiters(t *testing.T, w grin.WaitStrategy) {
+	var ready atomic.Bool
+
+	const waiters = 8
+	done := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			done <- w.Wait(context.Background(), ready.Load)
+		}()
+	}
+
+	time.Sleep(time.Millisecond)
+	ready.Store(true)
+	w.Notify()
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < waiters; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Wait() error = %v, want nil", err)
+			}
+		case <-timeout:
+			t.Fatal("Test timed out - waiter was not woken")
+		}
+	}
+}
+
+func TestFutexWaitContextCancel(t *testing.T) {
+	w := grin.NewFutexWait()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Wait(ctx, func() bool { return false })
+	}()
+
+	time.Sleep(time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test timed out - cancelled waiter was not released")
+	}
+}
+
+func TestFutexWaitPingPong(t *testing.T) {
+	w := grin.NewFutexWait()
+	ping := grin.New[int](1, grin.WithWaitStrategy(w))
+	pong := grin.New[int](1, grin.WithWaitStrategy(w))
+
+	const rounds = 10000
+	go func() {
+		for i := 0; i < rounds; i++ {
+			_ = w.Wait(context.Background(), func() bool { return ping.Len() > 0 })
+			v, _ := ping.Pop()
+			pong.Push(v)
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < rounds; i++ {
+			ping.Push(i)
+			_ = w.Wait(context.Background(), func() bool { return pong.Len() > 0 })
+			if v, _ := pong.Pop(); v != i {
+				t.Errorf("Pop() = %d, want %d", v, i)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Test timed out - wakeup was lost")
+	}
+}
+
+func TestWaitReadableWritable(t *testing.T) {
+	spill, err := grin.NewSpill[int](4, grin.FullReject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rings := map[string]grin.RingBuffer[int]{
+		"ring":          grin.New[int](4),
+		"publish every": grin.New[int](4, grin.WithPublishEvery(3)),
+		"spill":         spill,
+	}
+
+	for name, buf := range rings {
+		t.Run(name, func(t *testing.T) {
+			const n = 10000
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			go func() {
+				for i := 0; i < n; i++ {
+					for !buf.Push(i) {
+						if err := grin.WaitWritable(ctx, buf); err != nil {
+							return
+						}
+					}
+				}
+				buf.Flush()
+			}()
+
+			for i := 0; i < n; i++ {
+				v, ok := buf.Pop()
+				for !ok {
+					if err := grin.WaitReadable(ctx, buf); err != nil {
+						t.Fatalf("WaitReadable() error = %v waiting for item %d, want nil", err, i)
+					}
+					v, ok = buf.Pop()
+				}
+				if v != i {
+					t.Fatalf("Pop() = %d, want %d", v, i)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitReadableContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	buf := grin.New[int](4)
+	if err := grin.WaitReadable(ctx, buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitReadable() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	buf.Push(1)
+	buf.Push(2)
+	buf.Push(3)
+	buf.Push(4)
+	if err := grin.WaitWritable(ctx, buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitWritable() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}